@@ -13,9 +13,11 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
@@ -37,29 +39,83 @@ const (
 )
 
 const (
-	configType     = 0
-	createType     = 1
-	showType       = 2
-	wndMoveType    = 3
-	wndResizeType  = 4
-	keyDownType    = 5
-	keyUpType      = 6
-	msMoveType     = 7
-	buttonDownType = 8
-	buttonUpType   = 9
-	wheelType      = 10
-	updateType     = 11
-	closeType      = 12
-	destroyType    = 13
-	leaveType      = 14
-	swapIntervType = 15
-	textureType    = 16
-	texBufType     = 17
-	minimizeType   = 18
-	restoreType    = 19
-	focusType      = 20
-	customType     = 21
-	refreshType    = 22
+	configType        = 0
+	createType        = 1
+	showType          = 2
+	wndMoveType       = 3
+	wndResizeType     = 4
+	keyDownType       = 5
+	keyUpType         = 6
+	msMoveType        = 7
+	buttonDownType    = 8
+	buttonUpType      = 9
+	wheelType         = 10
+	updateType        = 11
+	closeType         = 12
+	destroyType       = 13
+	leaveType         = 14
+	swapIntervType    = 15
+	textureType       = 16
+	texBufType        = 17
+	minimizeType      = 18
+	restoreType       = 19
+	focusType         = 20
+	customType        = 21
+	refreshType       = 22
+	touchType         = 23
+	penType           = 24
+	gamepadConnType   = 25
+	gamepadButtonType = 26
+	gamepadAxisType   = 27
+	msEnterType       = 28
+	msLeaveType       = 29
+	monitorType       = 30
+	scaleType         = 31
+	maximizeType      = 32
+	endSessionType    = 33
+	suspendType       = 34
+	resumeType        = 35
+	occludedType      = 36
+	captureType       = 37
+)
+
+// Mouse buttons.
+const (
+	ButtonLeft = iota
+	ButtonRight
+	ButtonMiddle
+	ButtonX1
+	ButtonX2
+)
+
+// Modifier keys (bitmask).
+const (
+	ModShift = 1 << iota
+	ModCtrl
+	ModAlt
+	ModSuper
+)
+
+// Results of hit-test in HitRegion.
+const (
+	HitClient = iota
+	HitCaption
+	HitLeft
+	HitRight
+	HitTop
+	HitTopLeft
+	HitTopRight
+	HitBottom
+	HitBottomLeft
+	HitBottomRight
+)
+
+// Phases of touch and pen input.
+const (
+	TouchBegin = iota
+	TouchMove
+	TouchEnd
+	TouchCancel
 )
 
 var (
@@ -77,6 +133,7 @@ var (
 	mutex                   sync.Mutex
 	wnds                    []*tWindow
 	wndNextId               []int
+	wndIdsReleased          []int
 	requests                []tRequest
 	appTime                 tAppTime
 )
@@ -91,9 +148,18 @@ type Window interface {
 	OnKeyDown(keyCode int, repeated uint) error
 	OnKeyUp(keyCode int) error
 	OnMouseMove() error
-	OnButtonDown(buttonCode int, doubleClicked bool) error
-	OnButtonUp(buttonCode int, doubleClicked bool) error
+	OnMouseEnter() error
+	OnMouseLeave() error
+	OnButtonDown(button MouseButton) error
+	OnButtonUp(button MouseButton) error
 	OnWheel(rotation float32) error
+	OnTouch(touch Touch) error
+	OnPen(pen Pen) error
+	OnGamepadConnect(gamepadId int, connected bool) error
+	OnGamepadButton(gamepadId, button int, pressed bool) error
+	OnGamepadAxis(gamepadId, axis int, value float32) error
+	OnMonitorChanged(monitors []Monitor) error
+	OnContentScale(scale float32) error
 	OnCustom(obj interface{}) error
 	OnTextureLoaded(texture Texture) error
 	OnFramebufferCreated(buffer Framebuffer) error
@@ -101,8 +167,14 @@ type Window interface {
 	OnClose() (bool, error)
 	OnDestroy(error) error
 	OnMinimize() error
+	OnMaximize() error
 	OnRestore() error
 	OnFocus(focus bool) error
+	OnEndSession() error
+	OnSuspend() error
+	OnResume() error
+	OnOccluded(occluded bool) error
+	OnCapture(img *image.RGBA) error
 	Custom(obj interface{})
 	Update()
 	Close()
@@ -112,36 +184,120 @@ type Window interface {
 }
 
 // WindowImpl is the obligatory struct to embed, when using interface Window.
+// HitRegions are consulted by the hit-test of the window (first region
+// containing the mouse wins). Client area not covered by any region is
+// handled like before (Dragable, resize border of borderless window).
 type WindowImpl struct {
-	Props Properties
-	Stats Stats
-	Gfx   Graphics
-	id    int
-}
-
-// Configuration is the initial setting of window.
+	Props      Properties
+	Stats      Stats
+	Gfx        Graphics
+	Touches    []Touch
+	Gamepads   []Gamepad
+	HitRegions []HitRegion
+	id         int
+}
+
+// HitRegion is a rectangle in client area (same units as MouseX and
+// MouseY) with hit-test result Hit. Hit is one of HitClient,
+// HitCaption, HitLeft, HitRight, HitTop, HitTopLeft, HitTopRight,
+// HitBottom, HitBottomLeft or HitBottomRight. HitClient keeps e.g.
+// buttons inside a caption clickable.
+type HitRegion struct {
+	X, Y, Width, Height int
+	Hit                 int
+}
+
+// Configuration is the initial setting of window. ClientX and ClientY
+// are in screen coordinates (like in Properties). Monitor (index in
+// Monitors()) is the monitor the window is centered on (if Centered)
+// and whose content scale applies. ClientWidth and ClientHeight are in
+// logical units (scaled by monitor's content scale), ClientWidthMin,
+// ClientHeightMin, ClientWidthMax and ClientHeightMax are in pixels
+// (not scaled). Opacity is in range [0, 1]. Owned windows (Owner not
+// nil) stay above their owner, minimize and are destroyed with it.
+// Modal windows block input to their owner until they are closed.
+// Centered owned windows are centered over owner. If Exclusive is true,
+// Fullscreen changes the display to VideoMode (zero VideoMode keeps
+// desktop resolution). ClickInterval is the maximum time in
+// milliseconds between clicks counted as one multi-click (0 is the
+// system's double-click time). Name identifies the window in
+// WindowByName.
 type Configuration struct {
+	Monitor                           int
 	ClientX, ClientY                  int
 	ClientWidth, ClientHeight         int
 	ClientWidthMin, ClientHeightMin   int
 	ClientWidthMax, ClientHeightMax   int
 	MouseLocked, Borderless, Dragable bool
 	Resizable, Fullscreen, Centered   bool
+	Maximized                         bool
+	AlwaysOnTop, SkipTaskbar          bool
+	Opacity                           float32
+	Icon                              *Icon
+	Exclusive                         bool
+	VideoMode                         VideoMode
+	ClickInterval                     int
+	Owner                             Window
+	Modal                             bool
+	Name                              string
 	Title                             string
 }
 
-// Properties are the current window properties.
+// Properties are the current window properties. Position, sizes, MouseX
+// and MouseY are in pixels, while layers are drawn in logical units
+// (pixels divided by ContentScale, see Graphics.Width). MouseInside and
+// ContentScale are read only. Monitor is the index of the monitor the
+// window is on; changing it moves the window to another monitor.
+// Exclusive and VideoMode are like in Configuration. Desktop resolution
+// is restored, when window leaves fullscreen, loses focus or is
+// destroyed. Setting Attention flashes the taskbar button until window
+// gets focus. Occluded is read only and true, while window is not
+// visible (see OnOccluded). NormalX, NormalY, NormalWidth and
+// NormalHeight are read only and the client area, the window has when
+// it is neither minimized, maximized nor fullscreen.
 type Properties struct {
 	MouseX, MouseY                    int
+	MouseInside                       bool
+	Occluded                          bool
+	Monitor                           int
 	ClientX, ClientY                  int
 	ClientWidth, ClientHeight         int
+	NormalX, NormalY                  int
+	NormalWidth, NormalHeight         int
 	ClientWidthMin, ClientHeightMin   int
 	ClientWidthMax, ClientHeightMax   int
 	MouseLocked, Borderless, Dragable bool
 	Resizable, Fullscreen             bool
+	Minimized, Maximized              bool
+	AlwaysOnTop, SkipTaskbar          bool
+	Attention                         bool
+	Opacity                           float32
+	Icon                              *Icon
+	Exclusive                         bool
+	VideoMode                         VideoMode
+	ContentScale                      float32
 	Title                             string
 }
 
+// Monitor is a display. Position and size are in screen coordinates.
+// Work area is the area not covered by taskbar. Primary monitor has
+// index 0.
+type Monitor struct {
+	Name                  string
+	X, Y, Width, Height   int
+	WorkX, WorkY          int
+	WorkWidth, WorkHeight int
+	Scale                 float32
+	RefreshRate           int
+	Primary               bool
+}
+
+// VideoMode is a display mode of a monitor.
+type VideoMode struct {
+	Width, Height             int
+	RefreshRate, BitsPerPixel int
+}
+
 // Stats has useful data. Time is in milliseconds.
 type Stats struct {
 	AppTime, DeltaTime int
@@ -151,25 +307,35 @@ type Stats struct {
 	fps, lastFPSTime   int
 }
 
-// Graphics draws graphics.
+// Graphics draws graphics. Width and Height are the logical size,
+// i.e. the drawing area. FramebufferWidth and FramebufferHeight are
+// the size in pixels. (They are read only.)
 type Graphics struct {
-	BgR, BgG, BgB float32
-	VSync, AVSync bool
-	eventsChan    chan *tGraphicsEvent
-	quittedChan   chan bool
-	mutex         sync.Mutex
-	w, h          int
-	read          *tGfxBuffer
-	buffer        *tGfxBuffer
-	bufferReady   bool
-	updating      bool
-	running       bool
-	glTexIds      []int
-	texDims       []int
-	Layers        []Layer
-}
-
-// RectanglesLayer is a layer holding rectangles.
+	BgR, BgG, BgB                       float32
+	VSync, AVSync                       bool
+	Width, Height                       int
+	FramebufferWidth, FramebufferHeight int
+	s                                   float32
+	eventsChan                          chan *tGraphicsEvent
+	quittedChan                         chan bool
+	mutex                               sync.Mutex
+	w, h                                int
+	read                                *tGfxBuffer
+	buffer                              *tGfxBuffer
+	bufferReady                         bool
+	updating                            bool
+	running                             bool
+	glTexIds                            []int
+	texDims                             []int
+	capture                             bool
+	captureAll                          bool
+	captureRect                         image.Rectangle
+	Layers                              []Layer
+}
+
+// RectanglesLayer is a layer holding rectangles. The number of textures
+// is not limited; rectangles are drawn in multiple batches, if they use
+// more textures than one batch can sample.
 type RectanglesLayer struct {
 	entities     []*Rectangle
 	entityNextId []int
@@ -178,6 +344,30 @@ type RectanglesLayer struct {
 	texMap       []int
 }
 
+// FramebufferLayer draws Layers into the framebuffer with texture id
+// TextureId (see CreateFramebuffer) instead of window. Coordinates in
+// Layers are in pixels of the texture. Framebuffer is cleared with BgR,
+// BgG, BgB and BgA first. A disabled FramebufferLayer keeps the content
+// of the framebuffer, which can be used to cache it. To use the texture
+// in the same frame, FramebufferLayer must precede the layers using it.
+// Layers must not contain another FramebufferLayer.
+type FramebufferLayer struct {
+	Layers             []Layer
+	BgR, BgG, BgB, BgA float32
+	TextureId          int
+	Enabled            bool
+}
+
+type tFramebufferEnd struct {
+}
+
+// tRectanglesRest is the rest of a RectanglesLayer, that did not fit into
+// previous batch (starting with entity at entityIndex).
+type tRectanglesRest struct {
+	layer       *RectanglesLayer
+	entityIndex int
+}
+
 // Rectangle is an entity from a RectanglesLayer.
 type Rectangle struct {
 	id                   int
@@ -189,6 +379,72 @@ type Rectangle struct {
 	Enabled              bool
 }
 
+// SpritesLayer is a layer holding sprites. Frames are regions in
+// textures (atlas), that sprites refer to.
+type SpritesLayer struct {
+	entities     []*Sprite
+	entityNextId []int
+	count        int
+	Frames       []SpriteFrame
+	Enabled      bool
+	texMap       []int
+}
+
+// SpriteFrame is a region (in pixels) in texture with reference TexRef
+// (see SpritesLayer.UseTexture).
+type SpriteFrame struct {
+	TexRef              int
+	X, Y, Width, Height int
+}
+
+// Sprite is an entity from a SpritesLayer. It is drawn with Frame (index
+// in SpritesLayer.Frames) at X and Y. OriginX and OriginY are the pivot
+// in pixels of the frame; the sprite is scaled and rotated (in degrees)
+// around it. Negative scale flips the sprite. R, G, B tint the texture
+// and A is the alpha of the sprite. Sprites with invalid Frame are
+// drawn as rectangles of the tint color.
+type Sprite struct {
+	id               int
+	X, Y             float32
+	ScaleX, ScaleY   float32
+	Rotation         float32
+	OriginX, OriginY float32
+	R, G, B, A       float32
+	Frame            int
+	Enabled          bool
+}
+
+// MouseButton is a pressed or released mouse button. Code is one of
+// ButtonLeft, ButtonRight, ButtonMiddle, ButtonX1 (back) or ButtonX2
+// (forward). X and Y are the position in client area at the moment of
+// the click. Clicks is 1 for a single click, 2 for a double click, etc.
+// Modifiers is a bitmask of ModShift, ModCtrl, ModAlt and ModSuper.
+type MouseButton struct {
+	Code, X, Y        int
+	Clicks, Modifiers int
+}
+
+// Touch is a touch point. Phase is one of TouchBegin, TouchMove,
+// TouchEnd or TouchCancel. TouchCancel is sent when contact is lost
+// (e.g. another window captured it); X and Y may be 0 then. Pressure
+// is in range of [0, 1].
+type Touch struct {
+	Id, Phase int
+	X, Y      int
+	Pressure  float32
+}
+
+// Pen is the state of a pen. Phase is like in Touch. A hovering pen has
+// phase TouchMove and pressure 0, and TouchEnd when it leaves the
+// window. Tilt is in degrees.
+type Pen struct {
+	Id, Phase      int
+	X, Y           int
+	Pressure       float32
+	TiltX, TiltY   float32
+	Eraser, Barrel bool
+}
+
 // Texture provides a texture.
 type Texture interface {
 	Id() int
@@ -199,8 +455,9 @@ type Texture interface {
 	FilterLinear() bool
 }
 
-// Framebuffer provides a buffer to draw to.
+// Framebuffer is a texture to draw to (see FramebufferLayer).
 type Framebuffer interface {
+	Texture
 }
 
 type tWindow struct {
@@ -209,14 +466,20 @@ type tWindow struct {
 	abst            Window
 	impl            *WindowImpl
 	data            unsafe.Pointer
-	title           string
+	name, title     string
+	icon            *Icon
+	owner           *tWindow
+	hitRegions      []HitRegion
 	id, state, time int
 	update          bool
 }
 
 type tGfxBuffer struct {
 	w, h, sw    C.int
+	s           C.float
 	r, g, b     C.float
+	capture     bool
+	captureRect image.Rectangle
 	batches     [][]C.float
 	batchesPtrs []*C.float
 	lengths     []C.int
@@ -282,10 +545,18 @@ type tUpdateRequest struct {
 	wndId int
 }
 
+type tHitRegionsRequest struct {
+	regions []HitRegion
+	wndId   int
+}
+
 type tSetPropertiesRequest struct {
 	props                             Properties
 	modPosSize, modStyle              bool
 	modFullscreen, modMouse, modTitle bool
+	modMonitor, modVideoMode          bool
+	modWindowState, modAttributes     bool
+	modIcon                           bool
 	wndId                             int
 }
 
@@ -317,6 +588,19 @@ func ImageFromFile(path string) (image.Image, error) {
 	return img, err
 }
 
+// SavePNG writes image to file in PNG format.
+func SavePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err == nil {
+		err = png.Encode(file, img)
+		errClose := file.Close()
+		if err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
 // BytesFromImage returns bytes from image. (This is for test purposes.)
 func BytesFromImage(img image.Image) []byte {
 	var bytes []byte
@@ -345,6 +629,28 @@ func BytesFromImage(img image.Image) []byte {
 	return bytes
 }
 
+// Icon is a window icon. It holds images of multiple sizes; the best
+// fitting size is chosen for title bar and taskbar.
+type Icon struct {
+	dims   []C.int
+	pixels []byte
+}
+
+// NewIcon returns a new instance of Icon.
+func NewIcon(images ...image.Image) *Icon {
+	icon := new(Icon)
+	for _, img := range images {
+		if img != nil {
+			bounds := img.Bounds()
+			nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+			draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+			icon.dims = append(icon.dims, C.int(bounds.Dx()), C.int(bounds.Dy()))
+			icon.pixels = append(icon.pixels, nrgba.Pix...)
+		}
+	}
+	return icon
+}
+
 func newWindow(abst Window) *tWindow {
 	wnd := new(tWindow)
 	wnd.id = registerWnd(wnd)
@@ -369,6 +675,7 @@ func newWindow(abst Window) *tWindow {
 
 func newConfiguration() *Configuration {
 	config := new(Configuration)
+	config.Monitor = 0
 	config.ClientX = 50
 	config.ClientY = 50
 	config.ClientWidth = 640
@@ -383,10 +690,37 @@ func newConfiguration() *Configuration {
 	config.Resizable = true
 	config.Fullscreen = false
 	config.Centered = true
+	config.Maximized = false
+	config.AlwaysOnTop = false
+	config.SkipTaskbar = false
+	config.Opacity = 1
+	config.Icon = nil
+	config.Exclusive = false
+	config.ClickInterval = 0
+	config.Owner = nil
+	config.Modal = false
 	config.Title = "g2d - 0.1.0"
 	return config
 }
 
+func (config *Configuration) properties() Properties {
+	var props Properties
+	props.ClientX, props.ClientY = config.ClientX, config.ClientY
+	props.ClientWidth, props.ClientHeight = config.ClientWidth, config.ClientHeight
+	props.NormalX, props.NormalY = config.ClientX, config.ClientY
+	props.NormalWidth, props.NormalHeight = config.ClientWidth, config.ClientHeight
+	props.ClientWidthMin, props.ClientHeightMin = config.ClientWidthMin, config.ClientHeightMin
+	props.ClientWidthMax, props.ClientHeightMax = config.ClientWidthMax, config.ClientHeightMax
+	props.MouseLocked, props.Borderless, props.Dragable = config.MouseLocked, config.Borderless, config.Dragable
+	props.Resizable, props.Fullscreen = config.Resizable, config.Fullscreen
+	props.Maximized = config.Maximized
+	props.AlwaysOnTop, props.SkipTaskbar = config.AlwaysOnTop, config.SkipTaskbar
+	props.Opacity, props.Icon = config.Opacity, config.Icon
+	props.Exclusive, props.VideoMode = config.Exclusive, config.VideoMode
+	props.Monitor, props.Title = config.Monitor, config.Title
+	return props
+}
+
 func (config *Configuration) boolsToCInt() (C.int, C.int, C.int, C.int, C.int, C.int) {
 	var c, l, b, d, r, f C.int
 	if config.Centered {
@@ -430,6 +764,20 @@ func (props *Properties) boolsToCInt() (C.int, C.int, C.int, C.int, C.int) {
 	return l, b, d, r, f
 }
 
+func (props *Properties) attributesToCInt() (C.int, C.int, C.int) {
+	var t, s, a C.int
+	if props.AlwaysOnTop {
+		t = 1
+	}
+	if props.SkipTaskbar {
+		s = 1
+	}
+	if props.Attention {
+		a = 1
+	}
+	return t, s, a
+}
+
 func (props *Properties) compare(target *Properties) *tSetPropertiesRequest {
 	var req *tSetPropertiesRequest
 	if *props != *target {
@@ -444,10 +792,30 @@ func (props *Properties) compare(target *Properties) *tSetPropertiesRequest {
 		req.modFullscreen = bool(props.Fullscreen != target.Fullscreen)
 		req.modMouse = bool(props.MouseX != target.MouseX || props.MouseY != target.MouseY)
 		req.modTitle = bool(props.Title != target.Title)
+		req.modMonitor = bool(props.Monitor != target.Monitor)
+		req.modVideoMode = bool(props.Exclusive != target.Exclusive || props.VideoMode != target.VideoMode)
+		req.modWindowState = bool(props.Minimized != target.Minimized || props.Maximized != target.Maximized)
+		req.modAttributes = bool(props.AlwaysOnTop != target.AlwaysOnTop || props.SkipTaskbar != target.SkipTaskbar)
+		req.modAttributes = bool(req.modAttributes || props.Attention != target.Attention || props.Opacity != target.Opacity)
+		req.modIcon = bool(props.Icon != target.Icon)
+		req.modAttributes = bool(req.modAttributes || req.modIcon)
 	}
 	return req
 }
 
+func (mode *VideoMode) larger(other *VideoMode) bool {
+	if mode.Width != other.Width {
+		return mode.Width > other.Width
+	}
+	if mode.Height != other.Height {
+		return mode.Height > other.Height
+	}
+	if mode.RefreshRate != other.RefreshRate {
+		return mode.RefreshRate > other.RefreshRate
+	}
+	return mode.BitsPerPixel > other.BitsPerPixel
+}
+
 func (stats *Stats) updateUPS() {
 	diff := stats.AppTime - stats.lastUPSTime
 	if diff < 1000 {
@@ -505,7 +873,11 @@ func (gfx *Graphics) LoadTexture(texture Texture) {
 	}()
 }
 
-// CreateFramebuffer creates a buffer to draw to. [not implemented]
+// CreateFramebuffer creates a texture to draw to (see FramebufferLayer).
+// This is asynchronous. After the framebuffer has been created
+// OnFramebufferCreated is called. RGBABytes is the initial content and
+// may be nil. Mipmaps are not used. (Like in LoadTexture, a previous
+// texture with same id is released.)
 func (gfx *Graphics) CreateFramebuffer(texture Texture) {
 	go func() {
 		var w, h int
@@ -529,6 +901,19 @@ func (gfx *Graphics) CreateFramebuffer(texture Texture) {
 	}()
 }
 
+// Capture reads back the whole frame, after it has been drawn next time
+// (i.e. after next OnUpdate). The image is passed to OnCapture.
+func (gfx *Graphics) Capture() {
+	gfx.capture, gfx.captureAll = true, true
+}
+
+// CaptureRect is like Capture, but reads back only the rectangle at x,
+// y with width and height (in pixels).
+func (gfx *Graphics) CaptureRect(x, y, width, height int) {
+	gfx.capture, gfx.captureAll = true, false
+	gfx.captureRect = image.Rect(x, y, x+width, y+height)
+}
+
 func (gfx *Graphics) getReadBuffer() *tGfxBuffer {
 	if gfx.bufferReady {
 		tmp := gfx.buffer
@@ -539,6 +924,16 @@ func (gfx *Graphics) getReadBuffer() *tGfxBuffer {
 	return gfx.read
 }
 
+func (gfx *Graphics) sizeUpdate(props *Properties) {
+	gfx.w, gfx.h, gfx.s = props.ClientWidth, props.ClientHeight, props.ContentScale
+	if gfx.s <= 0 {
+		gfx.s = 1
+	}
+	gfx.FramebufferWidth, gfx.FramebufferHeight = gfx.w, gfx.h
+	gfx.Width = int(math.Round(float64(float32(gfx.w) / gfx.s)))
+	gfx.Height = int(math.Round(float64(float32(gfx.h) / gfx.s)))
+}
+
 func (gfx *Graphics) postRefresh() {
 	var swapInt int
 	gfx.mutex.Lock()
@@ -547,7 +942,16 @@ func (gfx *Graphics) postRefresh() {
 	} else if gfx.AVSync {
 		swapInt = -1
 	}
-	gfx.buffer.adopt(gfx.Layers, gfx.texDims, gfx.w, gfx.h, swapInt, gfx.BgR, gfx.BgG, gfx.BgB)
+	gfx.buffer.adopt(gfx.Layers, gfx.texDims, gfx.w, gfx.h, gfx.s, swapInt, gfx.BgR, gfx.BgG, gfx.BgB)
+	gfx.atlasesUpdate(gfx.Layers)
+	if gfx.capture {
+		gfx.buffer.capture, gfx.capture = true, false
+		if gfx.captureAll {
+			gfx.buffer.captureRect = image.Rect(0, 0, gfx.w, gfx.h)
+		} else {
+			gfx.buffer.captureRect = gfx.captureRect
+		}
+	}
 	gfx.bufferReady = true
 	if !gfx.updating {
 		gfx.updating = true
@@ -556,6 +960,20 @@ func (gfx *Graphics) postRefresh() {
 	gfx.mutex.Unlock()
 }
 
+// atlasesUpdate reloads glyph atlases, that got new glyphs in adopt.
+func (gfx *Graphics) atlasesUpdate(layers []Layer) {
+	for _, layer := range layers {
+		switch layerStruct := layer.(type) {
+		case *TextLayer:
+			if face, ok := layerStruct.Font.(*FontFace); ok && face.atlas != nil && face.atlas.takeModified() {
+				gfx.LoadTexture(face.atlas)
+			}
+		case *FramebufferLayer:
+			gfx.atlasesUpdate(layerStruct.Layers)
+		}
+	}
+}
+
 // NewEntity returns a new instance of Rectangle.
 func (layer *RectanglesLayer) NewEntity() *Rectangle {
 	var entity *Rectangle
@@ -584,16 +1002,85 @@ func (layer *RectanglesLayer) Release(r *Rectangle) *Rectangle {
 	return nil
 }
 
-// UseTexture associates a reference with a texture. Reference must be in range of [0, 15].
+// UseTexture associates a reference with a texture. Reference must not be negative.
 func (layer *RectanglesLayer) UseTexture(ref, textureId int) {
+	if ref >= 0 {
+		if textureId >= 0 && textureId < MaxTextures {
+			if len(layer.texMap) == 0 {
+				layer.texMap = make([]int, 16, 16)
+			}
+			for len(layer.texMap) <= ref {
+				layer.texMap = append(layer.texMap, 0)
+			}
+			layer.texMap[ref] = textureId
+		} else {
+			panic(fmt.Sprintf("invalid texture id (%d)", textureId))
+		}
+	} else {
+		panic(fmt.Sprintf("invalid reference (%d) for texture (%d)", ref, textureId))
+	}
+}
+
+// textureId returns texture associated with ref or -1, if ref is
+// invalid. References without texture are associated with texture 0.
+func (layer *RectanglesLayer) textureId(ref int) int {
+	if ref >= 0 {
+		if ref < len(layer.texMap) {
+			return layer.texMap[ref]
+		} else if ref < 16 {
+			return 0
+		}
+	}
+	return -1
+}
+
+// rectsTexLimit returns the number of textures a batch of rectangles can
+// sample (shader has 16 samplers).
+func rectsTexLimit() int {
+	if MaxTexUnits > 0 && MaxTexUnits < 16 {
+		return MaxTexUnits
+	}
+	return 16
+}
+
+// NewEntity returns a new instance of Sprite (untinted, not scaled).
+func (layer *SpritesLayer) NewEntity() *Sprite {
+	var entity *Sprite
+	if len(layer.entityNextId) == 0 {
+		entity = new(Sprite)
+		entity.id = len(layer.entities)
+		layer.entities = append(layer.entities, entity)
+	} else {
+		idLast := len(layer.entityNextId) - 1
+		id := layer.entityNextId[idLast]
+		layer.entityNextId = layer.entityNextId[:idLast]
+		entity = layer.entities[id]
+		entity.X, entity.Y, entity.Rotation, entity.OriginX, entity.OriginY = 0, 0, 0, 0, 0
+	}
+	entity.ScaleX, entity.ScaleY = 1, 1
+	entity.R, entity.G, entity.B, entity.A = 1, 1, 1, 1
+	entity.Frame = 0
+	entity.Enabled = true
+	layer.count++
+	return entity
+}
+
+// Release releases the entity. This entity may be reused when calling NewEntity.
+func (layer *SpritesLayer) Release(sprite *Sprite) *Sprite {
+	sprite.Enabled = false
+	layer.entityNextId = append(layer.entityNextId, sprite.id)
+	layer.count--
+	return nil
+}
+
+// UseTexture associates a reference with a texture. Reference must be in range of [0, 15].
+func (layer *SpritesLayer) UseTexture(ref, textureId int) {
 	if ref >= 0 && ref <= 15 {
-		if textureId >= 0 && textureId <= 79 {
-			if len(layer.texMap) != 0 {
-				layer.texMap[ref] = textureId
-			} else {
+		if textureId >= 0 && textureId < MaxTextures {
+			if len(layer.texMap) == 0 {
 				layer.texMap = make([]int, 16, 16)
-				layer.texMap[ref] = textureId
 			}
+			layer.texMap[ref] = textureId
 		} else {
 			panic(fmt.Sprintf("invalid texture id (%d)", textureId))
 		}
@@ -636,12 +1123,30 @@ func (wnd *tWindow) logicThread() {
 						wnd.onKeyUp(event.valA)
 					case msMoveType:
 						wnd.onMouseMove()
+					case msEnterType:
+						wnd.onMouseEnter()
+					case msLeaveType:
+						wnd.onMouseLeave()
 					case buttonDownType:
-						wnd.onButtonDown(event.valA, event.repeated != 0)
+						wnd.onButtonDown(event.obj.(MouseButton))
 					case buttonUpType:
-						wnd.onButtonUp(event.valA, event.repeated != 0)
+						wnd.onButtonUp(event.obj.(MouseButton))
 					case wheelType:
 						wnd.onWheel(event.valC)
+					case touchType:
+						wnd.onTouch(event.obj.(Touch))
+					case penType:
+						wnd.onPen(event.obj.(Pen))
+					case gamepadConnType:
+						wnd.onGamepadConnect(event.valA, event.valB != 0)
+					case gamepadButtonType:
+						wnd.onGamepadButton(event.valA, event.valB, event.valC != 0)
+					case gamepadAxisType:
+						wnd.onGamepadAxis(event.valA, event.valB, event.valC)
+					case monitorType:
+						wnd.onMonitorChanged(event.obj.([]Monitor))
+					case scaleType:
+						wnd.onContentScale(event.valC)
 					case updateType:
 						wnd.onUpdate()
 					case closeType:
@@ -649,13 +1154,25 @@ func (wnd *tWindow) logicThread() {
 					case textureType:
 						wnd.onTextureLoaded(event.obj.(Texture))
 					case texBufType:
-						wnd.onFramebufferCreated(event.obj.(Texture))
+						wnd.onFramebufferCreated(event.obj.(Framebuffer))
 					case minimizeType:
 						wnd.onMinimize()
+					case maximizeType:
+						wnd.onMaximize()
 					case restoreType:
 						wnd.onRestore()
 					case focusType:
 						wnd.onFocus(event.valA != 0)
+					case endSessionType:
+						wnd.onEndSession(event.obj.(chan bool))
+					case suspendType:
+						wnd.onSuspend()
+					case resumeType:
+						wnd.onResume()
+					case occludedType:
+						wnd.onOccluded(event.valA != 0)
+					case captureType:
+						wnd.onCapture(event.obj.(*image.RGBA))
 					case customType:
 						wnd.onCustom(event.obj)
 					case refreshType:
@@ -663,12 +1180,36 @@ func (wnd *tWindow) logicThread() {
 					}
 				}
 			}
+			if wnd.state == showingState {
+				wnd.hitRegionsUpdate()
+			}
 		}
 	}
 	<-wnd.impl.Gfx.quittedChan
 	wnd.quittedChan <- true
 }
 
+func (wnd *tWindow) hitRegionsUpdate() {
+	if !hitRegionsEqual(wnd.hitRegions, wnd.impl.HitRegions) {
+		wnd.hitRegions = append(wnd.hitRegions[:0], wnd.impl.HitRegions...)
+		regions := make([]HitRegion, len(wnd.hitRegions))
+		copy(regions, wnd.hitRegions)
+		postRequest(&tHitRegionsRequest{regions: regions, wndId: wnd.id})
+	}
+}
+
+func hitRegionsEqual(a, b []HitRegion) bool {
+	if len(a) == len(b) {
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (wnd *tWindow) onConfig() {
 	config := newConfiguration()
 	err := wnd.abst.OnConfig(config)
@@ -684,7 +1225,7 @@ func (wnd *tWindow) onCreate() {
 	go wnd.graphicsThread()
 	err := wnd.abst.OnCreate()
 	if err == nil {
-		wnd.impl.Gfx.w, wnd.impl.Gfx.h = wnd.impl.Props.ClientWidth, wnd.impl.Props.ClientHeight
+		wnd.impl.Gfx.sizeUpdate(&wnd.impl.Props)
 		wnd.impl.Gfx.postRefresh()
 		postRequest(&tShowWindowRequest{wndId: wnd.id})
 	} else {
@@ -727,7 +1268,7 @@ func (wnd *tWindow) onMove() {
 
 func (wnd *tWindow) onResize() {
 	props := wnd.impl.Props
-	wnd.impl.Gfx.w, wnd.impl.Gfx.h = props.ClientWidth, props.ClientHeight
+	wnd.impl.Gfx.sizeUpdate(&props)
 	err := wnd.abst.OnResize()
 	if err == nil {
 		setPropsReq := props.compare(&wnd.impl.Props)
@@ -786,9 +1327,24 @@ func (wnd *tWindow) onMouseMove() {
 	}
 }
 
-func (wnd *tWindow) onButtonDown(buttonCode int, doubleClicked bool) {
+func (wnd *tWindow) onMouseEnter() {
+	props := wnd.impl.Props
+	err := wnd.abst.OnMouseEnter()
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onMouseLeave() {
 	props := wnd.impl.Props
-	err := wnd.abst.OnButtonDown(buttonCode, doubleClicked)
+	err := wnd.abst.OnMouseLeave()
 	if err == nil {
 		setPropsReq := props.compare(&wnd.impl.Props)
 		if setPropsReq != nil {
@@ -801,9 +1357,24 @@ func (wnd *tWindow) onButtonDown(buttonCode int, doubleClicked bool) {
 	}
 }
 
-func (wnd *tWindow) onButtonUp(buttonCode int, doubleClicked bool) {
+func (wnd *tWindow) onButtonDown(button MouseButton) {
 	props := wnd.impl.Props
-	err := wnd.abst.OnButtonUp(buttonCode, doubleClicked)
+	err := wnd.abst.OnButtonDown(button)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onButtonUp(button MouseButton) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnButtonUp(button)
 	if err == nil {
 		setPropsReq := props.compare(&wnd.impl.Props)
 		if setPropsReq != nil {
@@ -831,11 +1402,120 @@ func (wnd *tWindow) onWheel(rotation float32) {
 	}
 }
 
+func (wnd *tWindow) onTouch(touch Touch) {
+	props := wnd.impl.Props
+	wnd.impl.Touches = touchesUpdated(wnd.impl.Touches, touch)
+	err := wnd.abst.OnTouch(touch)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onPen(pen Pen) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnPen(pen)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onGamepadConnect(gamepadId int, connected bool) {
+	props := wnd.impl.Props
+	wnd.impl.Gamepads = gamepadsSnapshot()
+	err := wnd.abst.OnGamepadConnect(gamepadId, connected)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onGamepadButton(gamepadId, button int, pressed bool) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnGamepadButton(gamepadId, button, pressed)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onGamepadAxis(gamepadId, axis int, value float32) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnGamepadAxis(gamepadId, axis, value)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onMonitorChanged(monitors []Monitor) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnMonitorChanged(monitors)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onContentScale(scale float32) {
+	props := wnd.impl.Props
+	wnd.impl.Gfx.sizeUpdate(&props)
+	err := wnd.abst.OnContentScale(scale)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
 func (wnd *tWindow) onUpdate() {
 	wnd.update = false
 	wnd.impl.Stats.DeltaTime = wnd.impl.Stats.AppTime - wnd.impl.Stats.lastUpdate
 	wnd.impl.Stats.lastUpdate = wnd.impl.Stats.AppTime
 	wnd.impl.Stats.updateUPS()
+	wnd.impl.Gamepads = gamepadsSnapshot()
 	props := wnd.impl.Props
 	err := wnd.abst.OnUpdate()
 	if err == nil {
@@ -940,6 +1620,21 @@ func (wnd *tWindow) onMinimize() {
 	}
 }
 
+func (wnd *tWindow) onMaximize() {
+	props := wnd.impl.Props
+	err := wnd.abst.OnMaximize()
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
 func (wnd *tWindow) onRestore() {
 	props := wnd.impl.Props
 	err := wnd.abst.OnRestore()
@@ -970,9 +1665,85 @@ func (wnd *tWindow) onFocus(focus bool) {
 	}
 }
 
-func (buf *tGfxBuffer) adopt(layers []Layer, texDims []int, w, h, sw int, r, g, b float32) {
+func (wnd *tWindow) onEndSession(done chan bool) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnEndSession()
+	done <- true
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onSuspend() {
+	props := wnd.impl.Props
+	err := wnd.abst.OnSuspend()
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onResume() {
+	props := wnd.impl.Props
+	err := wnd.abst.OnResume()
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onOccluded(occluded bool) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnOccluded(occluded)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (wnd *tWindow) onCapture(img *image.RGBA) {
+	props := wnd.impl.Props
+	err := wnd.abst.OnCapture(img)
+	if err == nil {
+		setPropsReq := props.compare(&wnd.impl.Props)
+		if setPropsReq != nil {
+			setPropsReq.wndId = wnd.id
+			postRequest(setPropsReq)
+		}
+	} else {
+		wnd.state = closingState
+		postRequest(&tErrorRequest{err: err})
+	}
+}
+
+func (buf *tGfxBuffer) adopt(layers []Layer, texDims []int, w, h int, s float32, sw int, r, g, b float32) {
 	var index int
-	buf.w, buf.h, buf.sw = C.int(w), C.int(h), C.int(sw)
+	buf.w, buf.h, buf.s, buf.sw = C.int(w), C.int(h), C.float(s), C.int(sw)
 	buf.r, buf.g, buf.b = C.float(r), C.float(g), C.float(b)
 	buf.batches = buf.batches[:0]
 	buf.batchesPtrs = buf.batchesPtrs[:0]
@@ -993,7 +1764,7 @@ func (buf *tGfxBuffer) adopt(layers []Layer, texDims []int, w, h, sw int, r, g,
 				buf.procs = buf.procs[:index+1]
 			}
 		}
-		layers, buf.batches[index], buf.lengths[index], buf.procs[index] = layers[index].getBatch(layers, texDims, buf.batches[index][:0])
+		layers, buf.batches[index], buf.lengths[index], buf.procs[index] = layers[0].getBatch(layers, texDims, buf.batches[index][:0])
 		if len(buf.batches[index]) > 0 {
 			buf.batchesPtrs = append(buf.batchesPtrs, &buf.batches[index][0])
 		} else {
@@ -1056,13 +1827,26 @@ func (wnd *WindowImpl) OnMouseMove() error {
 	return nil
 }
 
-// OnButtonDown is called when mouse button has been pressed.
-func (wnd *WindowImpl) OnButtonDown(buttonCode int, doubleClicked bool) error {
+// OnMouseEnter is called when mouse has entered the client area.
+func (wnd *WindowImpl) OnMouseEnter() error {
+	return nil
+}
+
+// OnMouseLeave is called when mouse has left the client area.
+func (wnd *WindowImpl) OnMouseLeave() error {
+	return nil
+}
+
+// OnButtonDown is called when mouse button has been pressed. While a
+// button is pressed, mouse is captured, i.e. mouse events are received
+// even if cursor leaves the client area.
+func (wnd *WindowImpl) OnButtonDown(button MouseButton) error {
 	return nil
 }
 
-// OnButtonUp is called when mouse button has been released.
-func (wnd *WindowImpl) OnButtonUp(buttonCode int, doubleClicked bool) error {
+// OnButtonUp is called when mouse button has been released. Clicks is
+// the same as in the corresponding OnButtonDown.
+func (wnd *WindowImpl) OnButtonUp(button MouseButton) error {
 	return nil
 }
 
@@ -1071,6 +1855,47 @@ func (wnd *WindowImpl) OnWheel(rotation float32) error {
 	return nil
 }
 
+// OnTouch is called when a touch point begins, moves, ends or is
+// canceled. wnd.Touches holds all active touch points.
+func (wnd *WindowImpl) OnTouch(touch Touch) error {
+	return nil
+}
+
+// OnPen is called when pen has touched, moved or left the surface.
+func (wnd *WindowImpl) OnPen(pen Pen) error {
+	return nil
+}
+
+// OnGamepadConnect is called when a gamepad has been connected or
+// disconnected. wnd.Gamepads holds all connected gamepads.
+func (wnd *WindowImpl) OnGamepadConnect(gamepadId int, connected bool) error {
+	return nil
+}
+
+// OnGamepadButton is called when a gamepad button has been pressed or
+// released. Button is one of GamepadA, GamepadB, etc.
+func (wnd *WindowImpl) OnGamepadButton(gamepadId, button int, pressed bool) error {
+	return nil
+}
+
+// OnGamepadAxis is called when a gamepad axis has been moved. Axis is
+// one of GamepadLeftX, GamepadLeftY, etc.
+func (wnd *WindowImpl) OnGamepadAxis(gamepadId, axis int, value float32) error {
+	return nil
+}
+
+// OnMonitorChanged is called when a display has been connected,
+// disconnected or its resolution has changed.
+func (wnd *WindowImpl) OnMonitorChanged(monitors []Monitor) error {
+	return nil
+}
+
+// OnContentScale is called when DPI of window has changed, e.g. when
+// moved to another monitor.
+func (wnd *WindowImpl) OnContentScale(scale float32) error {
+	return nil
+}
+
 // OnCustom is called after calling Custom().
 func (wnd *WindowImpl) OnCustom(obj interface{}) error {
 	return nil
@@ -1081,13 +1906,13 @@ func (wnd *WindowImpl) OnTextureLoaded(texture Texture) error {
 	return nil
 }
 
-// OnFramebufferCreated is called after texture has been created.
+// OnFramebufferCreated is called after framebuffer has been created.
 func (wnd *WindowImpl) OnFramebufferCreated(texture Framebuffer) error {
 	return nil
 }
 
 // OnCustom is called after calling Update(). After OnUpdate graphis
-// is redrawn.
+// is redrawn. wnd.Gamepads holds the current state of gamepads.
 func (wnd *WindowImpl) OnUpdate() error {
 	return nil
 }
@@ -1108,7 +1933,13 @@ func (wnd *WindowImpl) OnMinimize() error {
 	return nil
 }
 
-// OnRestore is called after window has returned from minimized state.
+// OnMaximize is called after window has been maximized.
+func (wnd *WindowImpl) OnMaximize() error {
+	return nil
+}
+
+// OnRestore is called after window has returned from minimized or
+// maximized state.
 func (wnd *WindowImpl) OnRestore() error {
 	return nil
 }
@@ -1118,19 +1949,48 @@ func (wnd *WindowImpl) OnFocus(focus bool) error {
 	return nil
 }
 
+// OnEndSession is called when the user logs off or the system shuts
+// down. The session ends after OnEndSession has returned, but at most
+// 4 seconds after it has been called.
+func (wnd *WindowImpl) OnEndSession() error {
+	return nil
+}
+
+// OnSuspend is called before the system goes to sleep.
+func (wnd *WindowImpl) OnSuspend() error {
+	return nil
+}
+
+// OnResume is called after the system has woken up from sleep.
+func (wnd *WindowImpl) OnResume() error {
+	return nil
+}
+
+// OnOccluded is called when window becomes fully covered by other
+// windows, minimized or hidden (occluded is true) and when it becomes
+// visible again. Rendering can be stopped while occluded.
+func (wnd *WindowImpl) OnOccluded(occluded bool) error {
+	return nil
+}
+
+// OnCapture is called after a frame has been read back (see Capture).
+func (wnd *WindowImpl) OnCapture(img *image.RGBA) error {
+	return nil
+}
+
 // Close triggers OnClose event.
 func (wnd *WindowImpl) Close() {
-	postRequest(&tCloseWindowRequest{wndId: wnd.id})
+	postWndRequest(wnd, func(wndId int) tRequest { return &tCloseWindowRequest{wndId: wndId} })
 }
 
 // Update triggers OnUpdate event.
 func (wnd *WindowImpl) Update() {
 	mutex.Lock()
-	wndWrapper := wnds[wnd.id]
-	if !wndWrapper.update {
+	if wnd.id >= 0 && !wnds[wnd.id].update {
+		wndWrapper := wnds[wnd.id]
 		wndWrapper.update = true
 		event := &tLogicEvent{typeId: updateType, time: appTime.Millis()}
-		event.props.update(wndWrapper.data, wndWrapper.title)
+		event.props.update(wndWrapper.data, wndWrapper.title, wndWrapper.icon)
 		wndWrapper.eventsChan <- event
 	}
 	mutex.Unlock()
@@ -1138,12 +1998,12 @@ func (wnd *WindowImpl) Update() {
 
 // Quit destroys window unconditionally.
 func (wnd *WindowImpl) Quit() {
-	postRequest(&tDestroyWindowRequest{wndId: wnd.id})
+	postWndRequest(wnd, func(wndId int) tRequest { return &tDestroyWindowRequest{wndId: wndId} })
 }
 
 // Custom triggers OnCustom event.
 func (wnd *WindowImpl) Custom(obj interface{}) {
-	postRequest(&tCustomRequest{wndId: wnd.id, obj: obj})
+	postWndRequest(wnd, func(wndId int) tRequest { return &tCustomRequest{wndId: wndId, obj: obj} })
 }
 
 // Show creates a new window.
@@ -1155,6 +2015,42 @@ func (wnd *WindowImpl) impl() *WindowImpl {
 	return wnd
 }
 
+// TouchGesture returns the pan (dx, dy), scale and rotation (in degrees)
+// from one set of touches to another. Only touches present in both sets
+// are considered. Scale and rotation need at least two of them.
+func TouchGesture(from, to []Touch) (float32, float32, float32, float32) {
+	var fromX, fromY, toX, toY []float64
+	for _, touchTo := range to {
+		for _, touchFrom := range from {
+			if touchFrom.Id == touchTo.Id {
+				fromX, fromY = append(fromX, float64(touchFrom.X)), append(fromY, float64(touchFrom.Y))
+				toX, toY = append(toX, float64(touchTo.X)), append(toY, float64(touchTo.Y))
+				break
+			}
+		}
+	}
+	dx, dy, scale, rotation := 0.0, 0.0, 1.0, 0.0
+	if len(toX) > 0 {
+		fcx, fcy := centroid(fromX, fromY)
+		tcx, tcy := centroid(toX, toY)
+		dx, dy = tcx-fcx, tcy-fcy
+		if len(toX) > 1 {
+			var fromDist, toDist float64
+			for i := range toX {
+				fromDist += math.Hypot(fromX[i]-fcx, fromY[i]-fcy)
+				toDist += math.Hypot(toX[i]-tcx, toY[i]-tcy)
+			}
+			if fromDist > 0 {
+				scale = toDist / fromDist
+			}
+			fromAngle := math.Atan2(fromY[1]-fromY[0], fromX[1]-fromX[0])
+			toAngle := math.Atan2(toY[1]-toY[0], toX[1]-toX[0])
+			rotation = math.Remainder(toAngle-fromAngle, 2*math.Pi) * 180 / math.Pi
+		}
+	}
+	return float32(dx), float32(dy), float32(scale), float32(rotation)
+}
+
 func (t *tAppTime) Reset() {
 	t.start = time.Now()
 }
@@ -1185,13 +2081,91 @@ func registerWnd(wnd *tWindow) int {
 	return id
 }
 
+// Windows returns all windows that have been created and not yet
+// destroyed. Other windows can be sent objects with Custom or closed
+// with Close. After a window has been destroyed, its Close, Custom,
+// Quit and Update do nothing.
+func Windows() []Window {
+	mutex.Lock()
+	windows := make([]Window, 0, len(wnds))
+	for _, wnd := range wnds {
+		if wnd != nil && wnd.data != nil {
+			windows = append(windows, wnd.abst)
+		}
+	}
+	mutex.Unlock()
+	return windows
+}
+
+// WindowByName returns the window created with Configuration.Name
+// name, or nil if there is none.
+func WindowByName(name string) Window {
+	var window Window
+	mutex.Lock()
+	for _, wnd := range wnds {
+		if wnd != nil && wnd.data != nil && wnd.name == name {
+			window = wnd.abst
+			break
+		}
+	}
+	mutex.Unlock()
+	return window
+}
+
 func unregisterWnd(id int) *tWindow {
 	wnd := wnds[id]
 	wnds[id] = nil
-	wndNextId = append(wndNextId, id)
+	// invalidate handle (e.g. from Windows)
+	if wnd != nil && wnd.impl != nil {
+		wnd.impl.id = -1
+	}
+	if processingRequests {
+		// pending requests might still refer to id
+		wndIdsReleased = append(wndIdsReleased, id)
+	} else {
+		wndNextId = append(wndNextId, id)
+	}
 	return wnd
 }
 
+func touchesUpdated(touches []Touch, touch Touch) []Touch {
+	touchesNew := make([]Touch, 0, len(touches)+1)
+	for _, touchOld := range touches {
+		if touchOld.Id != touch.Id {
+			touchesNew = append(touchesNew, touchOld)
+		}
+	}
+	if touch.Phase == TouchBegin || touch.Phase == TouchMove {
+		touchesNew = append(touchesNew, touch)
+	}
+	return touchesNew
+}
+
+func centroid(xs, ys []float64) (float64, float64) {
+	var x, y float64
+	for i := range xs {
+		x += xs[i]
+		y += ys[i]
+	}
+	return x / float64(len(xs)), y / float64(len(ys))
+}
+
+// captureFinish converts rows from bottom-up (OpenGL) to top-down and
+// makes the image opaque.
+func captureFinish(img *image.RGBA) {
+	width := img.Rect.Dx() * 4
+	for top, bottom := 0, img.Rect.Dy()-1; top < bottom; top, bottom = top+1, bottom-1 {
+		rowTop := img.Pix[top*img.Stride : top*img.Stride+width]
+		rowBottom := img.Pix[bottom*img.Stride : bottom*img.Stride+width]
+		for i := range rowTop {
+			rowTop[i], rowBottom[i] = rowBottom[i], rowTop[i]
+		}
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+}
+
 func ensureCFloatLen(arr []C.float, length int) []C.float {
 	arrLen := len(arr)
 	if arrLen < length {
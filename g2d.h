@@ -15,25 +15,43 @@ extern void g2d_main_loop();
 extern void g2d_post_request(long long *err1, long long *err2);
 extern void g2d_post_quit(long long *err1, long long *err2);
 extern void g2d_clean_up();
-extern void g2d_window_create(void **data, int cb_id, int x, int y, int w, int h, int wn, int hn, int wx, int hx, int b, int d, int r, int f, int l, int c, void *t, size_t ts, long long *err1, long long *err2);
+extern void g2d_window_create(void **data, int cb_id, int x, int y, int w, int h, int wn, int hn, int wx, int hx, int b, int d, int r, int f, int l, int c, int ci, int mn, void *owner, int modal, void *t, size_t ts, long long *err1, long long *err2);
 extern void g2d_window_show(void *data, long long *err1, long long *err2);
-extern void g2d_window_props(void *data, int *mx, int *my, int *x, int *y, int *w, int *h, int *wn, int *hn, int *wx, int *hx, int *b, int *d, int *r, int *f, int *l);
+extern void g2d_window_props(void *data, int *mx, int *my, int *mi, int *x, int *y, int *w, int *h, int *wn, int *hn, int *wx, int *hx, int *b, int *d, int *r, int *f, int *l, int *mn, int *e, int *vw, int *vh, int *vr, int *vb, float *cs, int *sn, int *sx, int *t, float *o, int *s, int *a, int *oc, int *nx, int *ny, int *nw, int *nh);
 extern void g2d_window_destroy(void *data, long long *err1, long long *err2);
 
 extern void g2d_window_pos_size_set(void *data, int x, int y, int width, int height);
-extern void g2d_window_style_set(void *data, int wn, int hn, int wx, int hx, int b, int d, int r, int f, int l);
+extern void g2d_window_style_set(void *data, int wn, int hn, int wx, int hx, int b, int d, int r, int f, int l, int t, float o, int s, int a, int *icon_dims, void *icon_pixels, int icon_n);
 extern void g2d_window_fullscreen_set(void *data, long long *err1, long long *err2);
 extern void g2d_window_restore_bak(void *data);
+extern void g2d_window_video_mode_set(void *data, int e, int w, int h, int r, int b);
+extern void g2d_window_video_mode_apply(void *data, long long *err1, long long *err2);
 extern void g2d_window_pos_apply(void *data, long long *err1, long long *err2);
 extern void g2d_window_move(void *data, long long *err1, long long *err2);
+extern void g2d_window_state_set(void *data, int sn, int sx);
+extern void g2d_window_hit_regions_set(void *data, int *regions, int n);
 extern void g2d_window_title_set(void *data, void *t, size_t ts, long long *err1, long long *err2);
 extern void g2d_mouse_pos_set(void *data, int x, int y, long long *err1, long long *err2);
+extern void g2d_window_monitor_set(void *data, int mn, long long *err1, long long *err2);
+
+extern void g2d_video_modes(int mn, int max, int *n, int *modes);
+extern void g2d_monitors(int max, int *n, int *rects, int *rates, float *scales, int *primary, char *names, int name_len);
+
+extern void g2d_gamepad_poll(int index, int *connected, int *buttons, float *axes, int *hat);
 
 extern void g2d_gfx_init(void *data, long long *err1, long long *err2, char **err_nfo);
 extern void g2d_gfx_release(void *data, long long *err1, long long *err2);
-extern void g2d_gfx_draw(void *data, int w, int h, int i, float r, float g, float b, float **buffs, const int *bs, void **procs, int l, long long *err1, long long *err2);
+extern void g2d_gfx_draw(void *data, int w, int h, float s, int i, float r, float g, float b, float **buffs, const int *bs, void **procs, int l, const int *cr, void *cp, long long *err1, long long *err2);
 extern void g2d_gfx_draw_rectangles(void *data, float *rects, int total, long long *err1);
+extern void g2d_gfx_draw_sprites(void *data, float *sprites, int total, long long *err1);
+extern void g2d_gfx_draw_triangles(void *data, float *vertices, int total, long long *err1);
+extern void g2d_gfx_draw_shapes(void *data, float *vertices, int total, long long *err1);
+extern void g2d_gfx_draw_mesh(void *data, float *mesh, int total, long long *err1);
+extern void g2d_gfx_draw_text_sdf(void *data, float *text, int total, long long *err1);
+extern void g2d_gfx_draw_fb_begin(void *data, float *fb, int total, long long *err1);
+extern void g2d_gfx_draw_fb_end(void *data, float *fb, int total, long long *err1);
 extern void g2d_gfx_gen_tex(void *data, const void *tex, int gen_mm, int is_mm, int lin, int w, int h, int *const texture, int tex_unit, long long *err1);
+extern void g2d_gfx_gen_fb(void *data, int texture, int tex_unit, long long *err1);
 
 #elif defined(G2D_LINUX)
 #endif
@@ -0,0 +1,214 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"image"
+	"math"
+	"testing"
+	"unsafe"
+)
+
+func TestTouchGesture(t *testing.T) {
+	from := []Touch{{Id: 1, X: 100, Y: 100}, {Id: 2, X: 200, Y: 100}}
+	to := []Touch{{Id: 1, X: 50, Y: 150}, {Id: 2, X: 250, Y: 150}, {Id: 3, X: 0, Y: 0}}
+	dx, dy, scale, rotation := TouchGesture(from, to)
+	if dx != 0 || dy != 50 {
+		t.Error("pan is", dx, dy)
+	}
+	if scale != 2 {
+		t.Error("scale is", scale)
+	}
+	if rotation != 0 {
+		t.Error("rotation is", rotation)
+	}
+	to = []Touch{{Id: 1, X: 150, Y: 50}, {Id: 2, X: 150, Y: 150}}
+	_, _, _, rotation = TouchGesture(from, to)
+	if math.Abs(float64(rotation)-90) > 0.001 {
+		t.Error("rotation is", rotation)
+	}
+}
+
+func TestTouchesUpdated(t *testing.T) {
+	touches := touchesUpdated(nil, Touch{Id: 1, Phase: TouchBegin})
+	touches = touchesUpdated(touches, Touch{Id: 2, Phase: TouchBegin})
+	touches = touchesUpdated(touches, Touch{Id: 1, Phase: TouchMove, X: 10})
+	if len(touches) != 2 || touches[1].X != 10 {
+		t.Error("touches not updated", touches)
+	}
+	touches = touchesUpdated(touches, Touch{Id: 2, Phase: TouchCancel})
+	if len(touches) != 1 || touches[0].Id != 1 {
+		t.Error("touch not removed", touches)
+	}
+}
+
+func TestGraphicsSizeUpdate(t *testing.T) {
+	var gfx Graphics
+	gfx.sizeUpdate(&Properties{ClientWidth: 960, ClientHeight: 720, ContentScale: 1.5})
+	if gfx.Width != 640 || gfx.Height != 480 {
+		t.Error("logical size is", gfx.Width, gfx.Height)
+	}
+	if gfx.FramebufferWidth != 960 || gfx.FramebufferHeight != 720 {
+		t.Error("framebuffer size is", gfx.FramebufferWidth, gfx.FramebufferHeight)
+	}
+	gfx.sizeUpdate(&Properties{ClientWidth: 640, ClientHeight: 480})
+	if gfx.Width != 640 || gfx.s != 1 {
+		t.Error("default scale not applied", gfx.Width, gfx.s)
+	}
+}
+
+func TestNewIcon(t *testing.T) {
+	small := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	big := image.NewGray(image.Rect(10, 10, 42, 42))
+	small.Pix[0], small.Pix[3] = 255, 255
+	big.Pix[0] = 128
+	icon := NewIcon(small, nil, big)
+	if len(icon.dims) != 4 || icon.dims[0] != 16 || icon.dims[2] != 32 || icon.dims[3] != 32 {
+		t.Error("dimensions are", icon.dims)
+	}
+	if len(icon.pixels) != (16*16+32*32)*4 {
+		t.Error("pixels length is", len(icon.pixels))
+	}
+	if icon.pixels[0] != 255 || icon.pixels[3] != 255 {
+		t.Error("first image not copied", icon.pixels[:4])
+	}
+	if p := icon.pixels[16*16*4:]; p[0] != 128 || p[1] != 128 || p[3] != 255 {
+		t.Error("second image not converted", p[:4])
+	}
+}
+
+func TestWindows(t *testing.T) {
+	var data int
+	wndA, wndB := new(WindowImpl), new(WindowImpl)
+	wndsBak := wnds
+	wnds = []*tWindow{{abst: wndA, name: "a", data: unsafe.Pointer(&data)}, nil, {abst: wndB, name: "b"}}
+	defer func() { wnds = wndsBak }()
+	if windows := Windows(); len(windows) != 1 || windows[0] != wndA {
+		t.Error("windows are", windows)
+	}
+	if window := WindowByName("a"); window != wndA {
+		t.Error("window a is", window)
+	}
+	if window := WindowByName("b"); window != nil {
+		t.Error("window b not created, but found", window)
+	}
+}
+
+func TestFramebufferLayer(t *testing.T) {
+	var rectsA, rectsB RectanglesLayer
+	rectsA.Enabled, rectsB.Enabled = true, true
+	rectsA.NewEntity()
+	rectsB.NewEntity()
+	fbLayer := &FramebufferLayer{Layers: []Layer{&rectsA}, TextureId: 1, Enabled: true}
+	texDims := make([]int, 32)
+	texDims[2], texDims[3] = 64, 32
+	buf := new(tGfxBuffer)
+	buf.adopt([]Layer{fbLayer, &rectsB}, texDims, 100, 100, 1, 0, 0, 0, 0)
+	if len(buf.lengths) != 4 || buf.lengths[0] != 1 || buf.lengths[1] != 1 || buf.lengths[2] != 1 || buf.lengths[3] != 1 {
+		t.Error("batches are", buf.lengths)
+	} else if buf.batches[0][1] != 64 || buf.batches[0][2] != 32 {
+		t.Error("framebuffer size is", buf.batches[0][1], buf.batches[0][2])
+	}
+	fbLayer.Enabled = false
+	buf.adopt([]Layer{fbLayer, &rectsB}, texDims, 100, 100, 1, 0, 0, 0, 0)
+	if len(buf.lengths) != 2 || buf.lengths[0] != 0 || buf.lengths[1] != 1 {
+		t.Error("batches of disabled framebuffer are", buf.lengths)
+	}
+}
+
+func TestCaptureFinish(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 3))
+	img.Pix = []byte{1, 1, 1, 0, 2, 2, 2, 0, 3, 3, 3, 0}
+	captureFinish(img)
+	if img.Pix[0] != 3 || img.Pix[4] != 2 || img.Pix[8] != 1 {
+		t.Error("rows not flipped", img.Pix)
+	}
+	if img.Pix[3] != 255 || img.Pix[7] != 255 || img.Pix[11] != 255 {
+		t.Error("not opaque", img.Pix)
+	}
+}
+
+func TestSpritesLayer(t *testing.T) {
+	var layerA, layerB SpritesLayer
+	layerA.Enabled, layerB.Enabled = true, true
+	layerA.Frames = []SpriteFrame{{TexRef: 0, X: 10, Y: 20, Width: 8, Height: 4}}
+	sprite := layerA.NewEntity()
+	sprite.X, sprite.Y, sprite.OriginX, sprite.OriginY, sprite.ScaleX = 100, 50, 2, 1, -2
+	layerB.NewEntity().Frame = 1
+	buf := new(tGfxBuffer)
+	buf.adopt([]Layer{&layerA, &layerB}, make([]int, 32), 100, 100, 1, 0, 0, 0, 0)
+	if len(buf.lengths) != 1 || buf.lengths[0] != 2 {
+		t.Error("batches are", buf.lengths)
+	} else {
+		rect := buf.batches[0][48:64]
+		if rect[0] != 104 || rect[1] != 49 || rect[2] != -16 || rect[3] != 4 || rect[8] != 0 || rect[9] != 10 || rect[13] != 100 {
+			t.Error("sprite is", rect)
+		}
+		if rect = buf.batches[0][64:80]; rect[8] != -1 || rect[4] != 1 {
+			t.Error("sprite without frame is", rect)
+		}
+	}
+}
+
+func TestSpritesTextures(t *testing.T) {
+	var layerA, layerB, layerC SpritesLayer
+	maxTextures := MaxTextures
+	MaxTextures = 16
+	defer func() { MaxTextures = maxTextures }()
+	layerA.Enabled, layerB.Enabled, layerC.Enabled = true, true, true
+	texDims := make([]int, 32)
+	texDims[5*2], texDims[5*2+1] = 64, 32
+	texDims[7*2], texDims[7*2+1] = 16, 8
+	layerA.UseTexture(1, 5)
+	layerB.UseTexture(1, 5)
+	layerC.UseTexture(1, 7)
+	for _, layer := range []*SpritesLayer{&layerA, &layerB, &layerC} {
+		layer.Frames = []SpriteFrame{{TexRef: 1, Width: 8, Height: 4}}
+		layer.NewEntity()
+	}
+	buf := new(tGfxBuffer)
+	buf.adopt([]Layer{&layerA, &layerB, &layerC}, texDims, 100, 100, 1, 0, 0, 0, 0)
+	if len(buf.lengths) != 2 || buf.lengths[0] != 2 || buf.lengths[1] != 1 {
+		t.Fatal("batches are", buf.lengths)
+	}
+	if header := buf.batches[0]; header[1] != 5 || header[16+2] != 64 || header[16+3] != 32 {
+		t.Error("header is", header[:48])
+	}
+	if header := buf.batches[1]; header[1] != 7 || header[16+2] != 16 || header[16+3] != 8 {
+		t.Error("header is", header[:48])
+	}
+}
+
+func TestRectanglesTextures(t *testing.T) {
+	var layer RectanglesLayer
+	maxTextures := MaxTextures
+	MaxTextures = 40
+	defer func() { MaxTextures = maxTextures }()
+	layer.Enabled = true
+	texDims := make([]int, MaxTextures*2)
+	for i := 0; i < 20; i++ {
+		layer.UseTexture(i, i+20)
+		texDims[(i+20)*2] = i + 1
+		layer.NewEntity().TexRef = i
+	}
+	// texture used twice in batch
+	layer.NewEntity().TexRef = 3
+	layer.NewEntity()
+	buf := new(tGfxBuffer)
+	buf.adopt([]Layer{&layer}, texDims, 100, 100, 1, 0, 0, 0, 0)
+	if len(buf.lengths) != 2 || buf.lengths[0] != 16 || buf.lengths[1] != 6 {
+		t.Fatal("batches are", buf.lengths)
+	}
+	first, second := buf.batches[0], buf.batches[1]
+	if first[0] != 20 || first[15] != 35 || first[16] != 1 || first[48+15*16+8] != 15 {
+		t.Error("first batch", first[:48])
+	}
+	if second[0] != 36 || second[3] != 39 || second[4] != 23 || second[5] != -1 || second[48+4*16+8] != 4 || second[48+5*16+8] != -1 {
+		t.Error("second batch", second[:48])
+	}
+}
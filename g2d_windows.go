@@ -18,11 +18,20 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"image"
 	"runtime"
+	"sort"
 	"strconv"
+	"time"
 	"unsafe"
 )
 
+// time OnEndSession may take before session ends
+const endSessionTimeout = 4 * time.Second
+
+// xinputMapping is SDL's mapping for XInput devices.
+const xinputMapping = "xinput,XInput Controller,a:b0,b:b1,back:b6,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b10,leftshoulder:b4,leftstick:b8,lefttrigger:a2,leftx:a0,lefty:a1,rightshoulder:b5,rightstick:b9,righttrigger:a5,rightx:a3,righty:a4,start:b7,x:b2,y:b3,"
+
 const (
 	functionFailedDummy    = "dummy window %s failed"
 	loadFunctionFailed     = "load %s function failed"
@@ -69,11 +78,13 @@ func MainLoop(mainWindow Window) {
 					appTime.Reset()
 					wnd := newWindow(mainWindow)
 					go wnd.logicThread()
+					gamepadsStart()
 					mutex.Unlock()
 					C.g2d_main_loop()
 					mutex.Lock()
 					running = false
 					mutex.Unlock()
+					gamepadsStop()
 					cleanUp()
 				} else {
 					mutex.Unlock()
@@ -91,15 +102,20 @@ func MainLoop(mainWindow Window) {
 	}
 }
 
-func (props *Properties) update(data unsafe.Pointer, title string) {
-	var mx, my, x, y, w, h, wn, hn, wx, hx, b, d, r, f, l C.int
-	C.g2d_window_props(data, &mx, &my, &x, &y, &w, &h, &wn, &hn, &wx, &hx, &b, &d, &r, &f, &l)
+func (props *Properties) update(data unsafe.Pointer, title string, icon *Icon) {
+	var mx, my, mi, x, y, w, h, wn, hn, wx, hx, b, d, r, f, l, mn, e, vw, vh, vr, vb C.int
+	var sn, sx, t, s, a, oc, nx, ny, nw, nh C.int
+	var cs, o C.float
+	C.g2d_window_props(data, &mx, &my, &mi, &x, &y, &w, &h, &wn, &hn, &wx, &hx, &b, &d, &r, &f, &l, &mn, &e, &vw, &vh, &vr, &vb, &cs, &sn, &sx, &t, &o, &s, &a, &oc, &nx, &ny, &nw, &nh)
 	props.MouseX = int(mx)
 	props.MouseY = int(my)
+	props.MouseInside = bool(mi != 0)
 	props.ClientX = int(x)
 	props.ClientY = int(y)
 	props.ClientWidth = int(w)
 	props.ClientHeight = int(h)
+	props.NormalX, props.NormalY = int(nx), int(ny)
+	props.NormalWidth, props.NormalHeight = int(nw), int(nh)
 	props.ClientWidthMin = int(wn)
 	props.ClientHeightMin = int(hn)
 	props.ClientWidthMax = int(wx)
@@ -109,9 +125,152 @@ func (props *Properties) update(data unsafe.Pointer, title string) {
 	props.Resizable = bool(r != 0)
 	props.Fullscreen = bool(f != 0)
 	props.MouseLocked = bool(l != 0)
+	props.Monitor = int(mn)
+	props.Exclusive = bool(e != 0)
+	props.VideoMode = VideoMode{int(vw), int(vh), int(vr), int(vb)}
+	props.ContentScale = float32(cs)
+	props.Minimized = bool(sn != 0)
+	props.Maximized = bool(sx != 0)
+	props.AlwaysOnTop = bool(t != 0)
+	props.Opacity = float32(o)
+	props.SkipTaskbar = bool(s != 0)
+	props.Attention = bool(a != 0)
+	props.Occluded = bool(oc != 0)
+	props.Icon = icon
 	props.Title = title
 }
 
+// VideoModes returns the display modes supported by monitor, sorted
+// from largest to smallest.
+func VideoModes(monitor int) []VideoMode {
+	const max = 512
+	var n C.int
+	var modes [max * 4]C.int
+	C.g2d_video_modes(C.int(monitor), max, &n, &modes[0])
+	videoModes := make([]VideoMode, int(n))
+	for i := range videoModes {
+		mode := modes[i*4 : i*4+4]
+		videoModes[i] = VideoMode{int(mode[0]), int(mode[1]), int(mode[2]), int(mode[3])}
+	}
+	sort.Slice(videoModes, func(i, j int) bool {
+		return videoModes[i].larger(&videoModes[j])
+	})
+	return videoModes
+}
+
+func (mode *VideoMode) set(data unsafe.Pointer, exclusive bool) {
+	var e C.int
+	if exclusive {
+		e = 1
+	}
+	C.g2d_window_video_mode_set(data, e, C.int(mode.Width), C.int(mode.Height), C.int(mode.RefreshRate), C.int(mode.BitsPerPixel))
+}
+
+func (props *Properties) styleSet(data unsafe.Pointer, iconMod bool) {
+	var iconDims *C.int
+	var iconPixels unsafe.Pointer
+	iconN := C.int(-1)
+	wn := C.int(props.ClientWidthMin)
+	hn := C.int(props.ClientHeightMin)
+	wx := C.int(props.ClientWidthMax)
+	hx := C.int(props.ClientHeightMax)
+	l, b, d, r, f := props.boolsToCInt()
+	t, s, a := props.attributesToCInt()
+	if iconMod {
+		iconN = 0
+		if props.Icon != nil && len(props.Icon.dims) > 0 {
+			iconDims, iconPixels = &props.Icon.dims[0], unsafe.Pointer(&props.Icon.pixels[0])
+			iconN = C.int(len(props.Icon.dims) / 2)
+		}
+	}
+	C.g2d_window_style_set(data, wn, hn, wx, hx, b, d, r, f, l, t, C.float(props.Opacity), s, a, iconDims, iconPixels, iconN)
+}
+
+// Monitors returns the connected displays. Primary monitor is first.
+func Monitors() []Monitor {
+	const max, nameLen = 16, 128
+	var n C.int
+	var rects [max * 8]C.int
+	var rates, primary [max]C.int
+	var scales [max]C.float
+	var names [max * nameLen]C.char
+	C.g2d_monitors(max, &n, &rects[0], &rates[0], &scales[0], &primary[0], &names[0], nameLen)
+	monitors := make([]Monitor, int(n))
+	for i := range monitors {
+		monitor := &monitors[i]
+		rect := rects[i*8 : i*8+8]
+		monitor.Name = C.GoString(&names[i*nameLen])
+		monitor.X, monitor.Y = int(rect[0]), int(rect[1])
+		monitor.Width, monitor.Height = int(rect[2]), int(rect[3])
+		monitor.WorkX, monitor.WorkY = int(rect[4]), int(rect[5])
+		monitor.WorkWidth, monitor.WorkHeight = int(rect[6]), int(rect[7])
+		monitor.Scale = float32(scales[i])
+		monitor.RefreshRate = int(rates[i])
+		monitor.Primary = bool(primary[i] != 0)
+	}
+	return monitors
+}
+
+// tXInputDevice is a gamepad read by XInput.
+type tXInputDevice struct {
+	index int
+}
+
+func (dev *tXInputDevice) poll(raw *tGamepadRaw) bool {
+	var connected, hat C.int
+	var buttons [11]C.int
+	var axes [6]C.float
+	C.g2d_gamepad_poll(C.int(dev.index), &connected, &buttons[0], &axes[0], &hat)
+	if connected != 0 {
+		if len(raw.buttons) == 0 {
+			raw.buttons = make([]bool, len(buttons))
+			raw.axes = make([]float32, len(axes))
+			raw.hats = make([]int, 1)
+		}
+		for i, button := range buttons {
+			raw.buttons[i] = bool(button != 0)
+		}
+		for i, axis := range axes {
+			raw.axes[i] = float32(axis)
+		}
+		raw.hats[0] = int(hat)
+	}
+	return connected != 0
+}
+
+func (dev *tXInputDevice) info() (string, string) {
+	return "xinput", "XInput Controller"
+}
+
+func (dev *tXInputDevice) mapping() *tGamepadMapping {
+	mapping, _ := parseGamepadMapping(xinputMapping)
+	return mapping
+}
+
+func (dev *tXInputDevice) close() {
+}
+
+// gamepadDevicesScan returns new devices, that are not in list devices.
+func gamepadDevicesScan(devices []tGamepadDevice) []tGamepadDevice {
+	var devicesNew []tGamepadDevice
+	var used [4]bool
+	for _, device := range devices {
+		if xinput, ok := device.(*tXInputDevice); ok {
+			used[xinput.index] = true
+		}
+	}
+	for i := range used {
+		if !used[i] {
+			var raw tGamepadRaw
+			device := &tXInputDevice{index: i}
+			if device.poll(&raw) {
+				devicesNew = append(devicesNew, device)
+			}
+		}
+	}
+	return devicesNew
+}
+
 func (wnd *tWindow) graphicsThread() {
 	var err1, err2 C.longlong
 	var errInfo *C.char
@@ -132,6 +291,8 @@ func (wnd *tWindow) graphicsThread() {
 						wnd.impl.Gfx.running = false
 					case textureType:
 						wnd.onGfxTexture(event.valC.(Texture), event.valD)
+					case texBufType:
+						wnd.onGfxFramebuffer(event.valC.(Texture), event.valD)
 					}
 				} else {
 					postRequest(&tErrorRequest{err: event.err})
@@ -150,12 +311,25 @@ func (wnd *tWindow) graphicsThread() {
 
 func (wnd *tWindow) onGfxRefresh() {
 	var err1, err2 C.longlong
+	var captureRect [4]C.int
+	var capturePix unsafe.Pointer
+	var img *image.RGBA
 	wnd.impl.Gfx.mutex.Lock()
 	wnd.impl.Gfx.updating = false
 	read := wnd.impl.Gfx.getReadBuffer()
 	wnd.impl.Gfx.mutex.Unlock()
 	batches, lengths, procs := read.batchesPtrs, read.lengths, read.procs
-	w, h, i, r, g, b := read.w, read.h, read.sw, read.r, read.g, read.b
+	w, h, s, i, r, g, b := read.w, read.h, read.s, read.sw, read.r, read.g, read.b
+	if read.capture {
+		read.capture = false
+		rect := read.captureRect.Intersect(image.Rect(0, 0, int(w), int(h)))
+		img = image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+		if !rect.Empty() {
+			// origin of OpenGL is bottom left
+			captureRect = [4]C.int{C.int(rect.Min.X), h - C.int(rect.Max.Y), C.int(rect.Dx()), C.int(rect.Dy())}
+			capturePix = unsafe.Pointer(&img.Pix[0])
+		}
+	}
 	if len(batches) > 0 {
 		// calling with &batches[0] may cause "pointer to unpinned Go pointer" error
 		// https://github.com/PowerDNS/lmdb-go/issues/28
@@ -163,11 +337,19 @@ func (wnd *tWindow) onGfxRefresh() {
 		for _, batch := range batches {
 			pinner.Pin(batch)
 		}
-		C.g2d_gfx_draw(wnd.data, w, h, i, r, b, g, &batches[0], &lengths[0], &procs[0], C.int(len(batches)), &err1, &err2)
+		C.g2d_gfx_draw(wnd.data, w, h, s, i, r, b, g, &batches[0], &lengths[0], &procs[0], C.int(len(batches)), &captureRect[0], capturePix, &err1, &err2)
 		pinner.Unpin()
 	} else {
 		// just draw background
-		C.g2d_gfx_draw(wnd.data, w, h, i, r, b, g, nil, nil, nil, 0, &err1, &err2)
+		C.g2d_gfx_draw(wnd.data, w, h, s, i, r, b, g, nil, nil, nil, 0, &captureRect[0], capturePix, &err1, &err2)
+	}
+	if err1 == 0 {
+		if img != nil {
+			captureFinish(img)
+			wnd.eventsChan <- &tLogicEvent{typeId: captureType, obj: img, time: appTime.Millis()}
+		}
+	} else {
+		postRequest(&tErrorRequest{err: toError(err1, err2, nil)})
 	}
 	wnd.eventsChan <- &tLogicEvent{typeId: refreshType, time: appTime.Millis()}
 }
@@ -194,46 +376,100 @@ func (wnd *tWindow) onGfxTexture(texture Texture, rgbaBytes []byte) {
 	}
 }
 
-func (layer *RectanglesLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+func (wnd *tWindow) onGfxFramebuffer(texture Texture, rgbaBytes []byte) {
+	var err1 C.longlong
+	var texData unsafe.Pointer
+	texUnit := texture.Id()
+	glTexId := C.int(wnd.impl.Gfx.glTexIds[texUnit])
+	texWidth, texHeight := texture.Dimensions()
+	if len(rgbaBytes) > 0 {
+		texData = unsafe.Pointer(&rgbaBytes[0])
+	}
+	_, _, fLin := boolToCInt3(false, false, texture.FilterLinear())
+	C.g2d_gfx_gen_tex(wnd.data, texData, 0, 0, fLin, C.int(texWidth), C.int(texHeight), &glTexId, C.int(texUnit), &err1)
+	if err1 == 0 {
+		C.g2d_gfx_gen_fb(wnd.data, glTexId, C.int(texUnit), &err1)
+	}
+	if err1 == 0 {
+		dimIndex := texUnit * 2
+		wnd.impl.Gfx.glTexIds[texUnit] = int(glTexId)
+		wnd.impl.Gfx.texDims[dimIndex+0] = texWidth
+		wnd.impl.Gfx.texDims[dimIndex+1] = texHeight
+		wnd.eventsChan <- &tLogicEvent{typeId: texBufType, obj: texture, time: appTime.Millis()}
+	} else {
+		postRequest(&tErrorRequest{err: toError(err1, 0, nil)})
+	}
+}
+
+func (layer *FramebufferLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	if layer.Enabled && layer.TextureId >= 0 && layer.TextureId*2 < len(texDims) {
+		// layers drawn to framebuffer are followed by the end of framebuffer
+		layersNew := make([]Layer, 0, len(layer.Layers)+len(layers))
+		layersNew = append(layersNew, layer.Layers...)
+		layersNew = append(layersNew, &tFramebufferEnd{})
+		layersNew = append(layersNew, layers[1:]...)
+		buffer = ensureCFloatLen(buffer, 7)
+		buffer[0] = C.float(layer.TextureId)
+		buffer[1] = C.float(texDims[layer.TextureId*2+0])
+		buffer[2] = C.float(texDims[layer.TextureId*2+1])
+		buffer[3] = C.float(layer.BgR)
+		buffer[4] = C.float(layer.BgG)
+		buffer[5] = C.float(layer.BgB)
+		buffer[6] = C.float(layer.BgA)
+		return layersNew, buffer, 1, unsafe.Pointer(C.g2d_gfx_draw_fb_begin)
+	}
+	return layers[1:], buffer, 0, unsafe.Pointer(C.g2d_gfx_draw_fb_begin)
+}
+
+func (layer *tFramebufferEnd) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	return layers[1:], buffer, 1, unsafe.Pointer(C.g2d_gfx_draw_fb_end)
+}
+
+func (layer *SpritesLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
 	var count int
+	if layer.texMap == nil {
+		layer.texMap = make([]int, 16, 16)
+	}
+	buffer = ensureCFloatLen(buffer, 48)
+	// texture references (16) and their dimensions (2*16)
+	for i, textureId := range layer.texMap {
+		buffer[i] = C.float(textureId)
+		buffer[16+i*2+0] = C.float(texDims[textureId*2+0])
+		buffer[16+i*2+1] = C.float(texDims[textureId*2+1])
+	}
 	for len(layers) > 0 {
-		if curr, ok := layers[0].(*RectanglesLayer); ok {
+		// layers with other textures are drawn in next batch
+		if curr, ok := layers[0].(*SpritesLayer); ok && texMapsEqual(curr.texMap, layer.texMap) {
 			if curr.Enabled && curr.count > 0 {
-				var index int
 				buffer = ensureCFloatLen(buffer, 48+(count+curr.count)*16)
-				if layer.texMap == nil {
-					layer.texMap = make([]int, 16, 16)
-				}
-				// texture references (16)
-				for index = 0; index < 16; index++ {
-					buffer[index] = C.float(layer.texMap[index])
-				}
-				// dimensions of textures (2*16)
-				for index = 16; index < 48; index++ {
-					buffer[index] = C.float(texDims[index-16])
-				}
-				for _, entity := range layer.entities {
+				index := 48 + count*16
+				// sprites are converted to rectangles
+				for _, entity := range curr.entities {
 					if entity.Enabled {
-						buffer[index+0] = C.float(entity.X)
-						buffer[index+1] = C.float(entity.Y)
-						buffer[index+2] = C.float(entity.Width)
-						buffer[index+3] = C.float(entity.Height)
+						var frame SpriteFrame
+						texRef := -1
+						if entity.Frame >= 0 && entity.Frame < len(curr.Frames) {
+							frame = curr.Frames[entity.Frame]
+							if frame.TexRef >= 0 && frame.TexRef <= 15 {
+								texRef = frame.TexRef
+							}
+						}
+						buffer[index+0] = C.float(entity.X - entity.OriginX*entity.ScaleX)
+						buffer[index+1] = C.float(entity.Y - entity.OriginY*entity.ScaleY)
+						buffer[index+2] = C.float(float32(frame.Width) * entity.ScaleX)
+						buffer[index+3] = C.float(float32(frame.Height) * entity.ScaleY)
 						buffer[index+4] = C.float(entity.R)
 						buffer[index+5] = C.float(entity.G)
 						buffer[index+6] = C.float(entity.B)
 						buffer[index+7] = C.float(entity.A)
-						if entity.TexRef >= 0 && entity.TexRef <= 15 {
-							buffer[index+8] = C.float(entity.TexRef)
-						} else {
-							buffer[index+8] = -1.0
-						}
-						buffer[index+9] = C.float(entity.TexX)
-						buffer[index+10] = C.float(entity.TexY)
-						buffer[index+11] = C.float(entity.TexWidth)
-						buffer[index+12] = C.float(entity.TexHeight)
-						buffer[index+13] = C.float(entity.X + entity.RotX)
-						buffer[index+14] = C.float(entity.Y + entity.RotY)
-						buffer[index+15] = C.float(entity.RotAlpha)
+						buffer[index+8] = C.float(texRef)
+						buffer[index+9] = C.float(frame.X)
+						buffer[index+10] = C.float(frame.Y)
+						buffer[index+11] = C.float(frame.Width)
+						buffer[index+12] = C.float(frame.Height)
+						buffer[index+13] = C.float(entity.X)
+						buffer[index+14] = C.float(entity.Y)
+						buffer[index+15] = C.float(entity.Rotation)
 						index += 16
 						count++
 					}
@@ -244,9 +480,263 @@ func (layer *RectanglesLayer) getBatch(layers []Layer, texDims []int, buffer []C
 			break
 		}
 	}
+	return layers, buffer, C.int(count), unsafe.Pointer(C.g2d_gfx_draw_sprites)
+}
+
+func (layer *LinesLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	vertices := layer.vertices[:0]
+	for len(layers) > 0 {
+		if curr, ok := layers[0].(*LinesLayer); ok {
+			if curr.Enabled && curr.count > 0 {
+				for _, entity := range curr.entities {
+					if entity.Enabled {
+						vertices = entity.appendTriangles(vertices)
+					}
+				}
+			}
+			layers = layers[1:]
+		} else {
+			break
+		}
+	}
+	// vertices are x, y, r, g, b, a
+	buffer = ensureCFloatLen(buffer, len(vertices))
+	for i, value := range vertices {
+		buffer[i] = C.float(value)
+	}
+	layer.vertices = vertices
+	return layers, buffer, C.int(len(vertices) / 6), unsafe.Pointer(C.g2d_gfx_draw_triangles)
+}
+
+func (layer *ShapesLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	vertices := layer.vertices[:0]
+	for len(layers) > 0 {
+		if curr, ok := layers[0].(*ShapesLayer); ok {
+			if curr.Enabled && curr.count > 0 {
+				for _, entity := range curr.entities {
+					if entity.Enabled {
+						vertices = entity.appendVertices(vertices)
+					}
+				}
+			}
+			layers = layers[1:]
+		} else {
+			break
+		}
+	}
+	buffer = ensureCFloatLen(buffer, len(vertices))
+	for i, value := range vertices {
+		buffer[i] = C.float(value)
+	}
+	layer.vertices = vertices
+	return layers, buffer, C.int(len(vertices) / shapeVertexLen), unsafe.Pointer(C.g2d_gfx_draw_shapes)
+}
+
+func (layer *MeshLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	if layer.texMap == nil {
+		layer.texMap = make([]int, 16, 16)
+	}
+	// texture references (16)
+	vertices := layer.vertices[:0]
+	for _, textureId := range layer.texMap {
+		vertices = append(vertices, float32(textureId))
+	}
+	for len(layers) > 0 {
+		// layers with other textures are drawn in next batch
+		if curr, ok := layers[0].(*MeshLayer); ok && texMapsEqual(curr.texMap, layer.texMap) {
+			if curr.Enabled && curr.count > 0 {
+				for _, entity := range curr.entities {
+					if entity.Enabled {
+						var texWidth, texHeight float32
+						if entity.TexRef >= 0 && entity.TexRef <= 15 && layer.texMap[entity.TexRef]*2+1 < len(texDims) {
+							texWidth = float32(texDims[layer.texMap[entity.TexRef]*2+0])
+							texHeight = float32(texDims[layer.texMap[entity.TexRef]*2+1])
+						}
+						vertices = entity.appendVertices(vertices, texWidth, texHeight)
+					}
+				}
+			}
+			layers = layers[1:]
+		} else {
+			break
+		}
+	}
+	buffer = ensureCFloatLen(buffer, len(vertices))
+	for i, value := range vertices {
+		buffer[i] = C.float(value)
+	}
+	layer.vertices = vertices
+	return layers, buffer, C.int((len(vertices) - 16) / meshVertexLen), unsafe.Pointer(C.g2d_gfx_draw_mesh)
+}
+
+func (layer *TextLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	if layer.texMap == nil {
+		layer.texMap = make([]int, 16, 16)
+	}
+	if layer.distanceFace() != nil {
+		return layer.getDistanceBatch(layers, texDims, buffer)
+	}
+	// texture references (16) and their dimensions (2*16)
+	rects := layer.rects[:0]
+	for _, textureId := range layer.texMap {
+		rects = append(rects, float32(textureId))
+	}
+	for _, textureId := range layer.texMap {
+		rects = append(rects, float32(texDims[textureId*2+0]), float32(texDims[textureId*2+1]))
+	}
+	for len(layers) > 0 {
+		// layers with other textures are drawn in next batch
+		if curr, ok := layers[0].(*TextLayer); ok && curr.distanceFace() == nil && texMapsEqual(curr.texMap, layer.texMap) {
+			if curr.Enabled && curr.count > 0 && curr.Font != nil {
+				for _, entity := range curr.entities {
+					if entity.Enabled {
+						rects = entity.appendRects(rects, curr.Font)
+					}
+				}
+			}
+			layers = layers[1:]
+		} else {
+			break
+		}
+	}
+	buffer = ensureCFloatLen(buffer, len(rects))
+	for i, value := range rects {
+		buffer[i] = C.float(value)
+	}
+	layer.rects = rects
+	// glyphs are tinted like sprites
+	return layers, buffer, C.int((len(rects) - 48) / 16), unsafe.Pointer(C.g2d_gfx_draw_sprites)
+}
+
+func (layer *TextLayer) getDistanceBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	// texture references (16)
+	vertices := layer.rects[:0]
+	for _, textureId := range layer.texMap {
+		vertices = append(vertices, float32(textureId))
+	}
+	for len(layers) > 0 {
+		// layers with other textures or bitmap fonts are drawn in next batch
+		if curr, ok := layers[0].(*TextLayer); ok && curr.distanceFace() != nil && texMapsEqual(curr.texMap, layer.texMap) {
+			face := curr.distanceFace()
+			if curr.Enabled && curr.count > 0 && layer.texMap[0]*2+1 < len(texDims) {
+				// atlas is page 0
+				texWidth, texHeight := float32(texDims[layer.texMap[0]*2+0]), float32(texDims[layer.texMap[0]*2+1])
+				for _, entity := range curr.entities {
+					if entity.Enabled {
+						vertices = entity.appendDistanceVertices(vertices, face, texWidth, texHeight)
+					}
+				}
+			}
+			layers = layers[1:]
+		} else {
+			break
+		}
+	}
+	buffer = ensureCFloatLen(buffer, len(vertices))
+	for i, value := range vertices {
+		buffer[i] = C.float(value)
+	}
+	layer.rects = vertices
+	return layers, buffer, C.int((len(vertices) - 16) / sdfVertexLen), unsafe.Pointer(C.g2d_gfx_draw_text_sdf)
+}
+
+func (layer *RectanglesLayer) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	return rectanglesBatch(layers, texDims, buffer)
+}
+
+func (rest *tRectanglesRest) getBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	return rectanglesBatch(layers, texDims, buffer)
+}
+
+// rectanglesBatch merges consecutive rectangles layers. Textures are
+// assigned to samplers of the batch; when samplers are exhausted, the
+// remaining rectangles are drawn in next batch.
+func rectanglesBatch(layers []Layer, texDims []int, buffer []C.float) ([]Layer, []C.float, C.int, unsafe.Pointer) {
+	var count int
+	var texIds [16]int
+	var texCount int
+	texLimit := rectsTexLimit()
+	index := 48
+	for len(layers) > 0 {
+		var curr *RectanglesLayer
+		var entityIndex int
+		switch layerStruct := layers[0].(type) {
+		case *RectanglesLayer:
+			curr = layerStruct
+		case *tRectanglesRest:
+			curr, entityIndex = layerStruct.layer, layerStruct.entityIndex
+		}
+		if curr == nil {
+			break
+		}
+		if curr.Enabled && curr.count > 0 {
+			buffer = ensureCFloatLen(buffer, 48+(count+curr.count)*16)
+			for ; entityIndex < len(curr.entities); entityIndex++ {
+				entity := curr.entities[entityIndex]
+				if entity.Enabled {
+					slot := -1
+					if textureId := curr.textureId(entity.TexRef); textureId >= 0 {
+						for i := 0; i < texCount && slot < 0; i++ {
+							if texIds[i] == textureId {
+								slot = i
+							}
+						}
+						if slot < 0 {
+							if texCount == texLimit {
+								// rest of layer is drawn in next batch
+								layersNew := make([]Layer, 0, len(layers))
+								layersNew = append(layersNew, &tRectanglesRest{layer: curr, entityIndex: entityIndex})
+								layers = append(layersNew, layers[1:]...)
+								rectanglesHeader(buffer, texIds[:texCount], texDims)
+								return layers, buffer, C.int(count), unsafe.Pointer(C.g2d_gfx_draw_rectangles)
+							}
+							slot = texCount
+							texIds[texCount] = textureId
+							texCount++
+						}
+					}
+					buffer[index+0] = C.float(entity.X)
+					buffer[index+1] = C.float(entity.Y)
+					buffer[index+2] = C.float(entity.Width)
+					buffer[index+3] = C.float(entity.Height)
+					buffer[index+4] = C.float(entity.R)
+					buffer[index+5] = C.float(entity.G)
+					buffer[index+6] = C.float(entity.B)
+					buffer[index+7] = C.float(entity.A)
+					buffer[index+8] = C.float(slot)
+					buffer[index+9] = C.float(entity.TexX)
+					buffer[index+10] = C.float(entity.TexY)
+					buffer[index+11] = C.float(entity.TexWidth)
+					buffer[index+12] = C.float(entity.TexHeight)
+					buffer[index+13] = C.float(entity.X + entity.RotX)
+					buffer[index+14] = C.float(entity.Y + entity.RotY)
+					buffer[index+15] = C.float(entity.RotAlpha)
+					index += 16
+					count++
+				}
+			}
+		}
+		layers = layers[1:]
+	}
+	buffer = ensureCFloatLen(buffer, 48)
+	rectanglesHeader(buffer, texIds[:texCount], texDims)
 	return layers, buffer, C.int(count), unsafe.Pointer(C.g2d_gfx_draw_rectangles)
 }
 
+// rectanglesHeader writes texture units of samplers (16) and dimensions
+// of textures (2*16). Unused samplers are -1.
+func rectanglesHeader(buffer []C.float, texIds []int, texDims []int) {
+	for i := 0; i < 16; i++ {
+		if i < len(texIds) && texIds[i]*2+1 < len(texDims) {
+			buffer[i] = C.float(texIds[i])
+			buffer[16+i*2+0] = C.float(texDims[texIds[i]*2+0])
+			buffer[16+i*2+1] = C.float(texDims[texIds[i]*2+1])
+		} else {
+			buffer[i], buffer[16+i*2+0], buffer[16+i*2+1] = -1, 0, 0
+		}
+	}
+}
+
 func (request *tConfigWindowRequest) process() {
 	wnd := newWindow(request.window)
 	go wnd.logicThread()
@@ -255,9 +745,11 @@ func (request *tConfigWindowRequest) process() {
 
 func (request *tCreateWindowRequest) process() {
 	var err1, err2 C.longlong
-	var data unsafe.Pointer
+	var data, owner unsafe.Pointer
 	var t unsafe.Pointer
 	var ts C.size_t
+	var modal C.int
+	wnd := wnds[request.wndId]
 	x := C.int(request.config.ClientX)
 	y := C.int(request.config.ClientY)
 	w := C.int(request.config.ClientWidth)
@@ -266,18 +758,36 @@ func (request *tCreateWindowRequest) process() {
 	hn := C.int(request.config.ClientHeightMin)
 	wx := C.int(request.config.ClientWidthMax)
 	hx := C.int(request.config.ClientHeightMax)
+	ci := C.int(request.config.ClickInterval)
+	mn := C.int(request.config.Monitor)
+	if request.config.Owner != nil && request.config.Owner.impl() != nil {
+		ownerWnd := wnds[request.config.Owner.impl().id]
+		if ownerWnd != nil && ownerWnd.data != nil {
+			wnd.owner, owner = ownerWnd, ownerWnd.data
+			if request.config.Modal {
+				modal = 1
+			}
+		}
+	}
 	c, l, b, d, r, f := request.config.boolsToCInt()
 	if len(request.config.Title) > 0 {
 		bytes := *(*[]byte)(unsafe.Pointer(&(request.config.Title)))
 		t, ts = unsafe.Pointer(&bytes[0]), C.size_t(len(request.config.Title))
 	}
-	C.g2d_window_create(&data, C.int(request.wndId), x, y, w, h, wn, hn, wx, hx, b, d, r, f, l, c, t, ts, &err1, &err2)
+	C.g2d_window_create(&data, C.int(request.wndId), x, y, w, h, wn, hn, wx, hx, b, d, r, f, l, c, ci, mn, owner, modal, t, ts, &err1, &err2)
 	if err1 == 0 {
-		wnd := wnds[request.wndId]
 		wnd.data = data
+		wnd.name = request.config.Name
 		wnd.title = request.config.Title
+		wnd.icon = request.config.Icon
+		request.config.VideoMode.set(data, request.config.Exclusive)
+		props := request.config.properties()
+		props.styleSet(data, true)
+		if request.config.Maximized {
+			C.g2d_window_state_set(data, 0, 1)
+		}
 		event := &tLogicEvent{typeId: createType, time: appTime.Millis()}
-		event.props.update(data, wnd.title)
+		event.props.update(data, wnd.title, wnd.icon)
 		wnd.eventsChan <- event
 	} else {
 		Err = toError(err1, err2, nil)
@@ -287,10 +797,14 @@ func (request *tCreateWindowRequest) process() {
 func (request *tShowWindowRequest) process() {
 	var err1, err2 C.longlong
 	wnd := wnds[request.wndId]
+	// window might have been destroyed meanwhile (with its owner)
+	if wnd == nil || wnd.data == nil {
+		return
+	}
 	C.g2d_window_show(wnd.data, &err1, &err2)
 	if err1 == 0 {
 		event := &tLogicEvent{typeId: showType, time: appTime.Millis()}
-		event.props.update(wnd.data, wnd.title)
+		event.props.update(wnd.data, wnd.title, wnd.icon)
 		wnd.eventsChan <- event
 	} else {
 		Err = toError(err1, err2, nil)
@@ -299,14 +813,28 @@ func (request *tShowWindowRequest) process() {
 
 func (request *tCloseWindowRequest) process() {
 	wnd := wnds[request.wndId]
+	// window might have been destroyed meanwhile (with its owner)
+	if wnd == nil || wnd.data == nil {
+		return
+	}
 	event := &tLogicEvent{typeId: closeType, time: appTime.Millis()}
-	event.props.update(wnd.data, wnd.title)
+	event.props.update(wnd.data, wnd.title, wnd.icon)
 	wnd.eventsChan <- event
 }
 
 func (request *tDestroyWindowRequest) process() {
 	var err1, err2 C.longlong
 	wnd := wnds[request.wndId]
+	// window might have been destroyed meanwhile (with its owner)
+	if wnd == nil || wnd.data == nil {
+		return
+	}
+	// owned windows are destroyed before their owner
+	for _, owned := range wnds {
+		if owned != nil && owned.owner == wnd && owned.data != nil {
+			(&tDestroyWindowRequest{wndId: owned.id}).process()
+		}
+	}
 	wnd.eventsChan <- &tLogicEvent{typeId: destroyType, time: appTime.Millis()}
 	<-wnd.quittedChan
 	C.g2d_window_destroy(wnd.data, &err1, &err2)
@@ -319,24 +847,49 @@ func (request *tDestroyWindowRequest) process() {
 
 func (request *tCustomRequest) process() {
 	wnd := wnds[request.wndId]
-	event := &tLogicEvent{typeId: customType, obj: request.obj}
-	event.props.update(wnd.data, wnd.title)
-	wnd.eventsChan <- event
+	// window might have been destroyed meanwhile
+	if wnd != nil && wnd.data != nil {
+		event := &tLogicEvent{typeId: customType, obj: request.obj}
+		event.props.update(wnd.data, wnd.title, wnd.icon)
+		wnd.eventsChan <- event
+	}
+}
+
+func (request *tHitRegionsRequest) process() {
+	wnd := wnds[request.wndId]
+	if wnd != nil && wnd.data != nil {
+		var regionsPtr *C.int
+		regions := make([]C.int, 0, len(request.regions)*5)
+		for _, region := range request.regions {
+			regions = append(regions, C.int(region.X), C.int(region.Y), C.int(region.Width), C.int(region.Height), C.int(region.Hit))
+		}
+		if len(regions) > 0 {
+			regionsPtr = &regions[0]
+		}
+		C.g2d_window_hit_regions_set(wnd.data, regionsPtr, C.int(len(request.regions)))
+	}
 }
 
 func (request *tSetPropertiesRequest) process() {
 	var err1, err2 C.longlong
 	wnd := wnds[request.wndId]
+	if wnd == nil || wnd.data == nil {
+		return
+	}
 	if request.modPosSize {
 		C.g2d_window_pos_size_set(wnd.data, C.int(request.props.ClientX), C.int(request.props.ClientY), C.int(request.props.ClientWidth), C.int(request.props.ClientHeight))
 	}
-	if request.modStyle || request.modFullscreen {
-		wn := C.int(request.props.ClientWidthMin)
-		hn := C.int(request.props.ClientHeightMin)
-		wx := C.int(request.props.ClientWidthMax)
-		hx := C.int(request.props.ClientHeightMax)
-		l, b, d, r, f := request.props.boolsToCInt()
-		C.g2d_window_style_set(wnd.data, wn, hn, wx, hx, b, d, r, f, l)
+	if request.modVideoMode {
+		request.props.VideoMode.set(wnd.data, request.props.Exclusive)
+		if !request.modFullscreen {
+			C.g2d_window_video_mode_apply(wnd.data, &err1, &err2)
+		}
+	}
+	if request.modStyle || request.modFullscreen || request.modAttributes {
+		if request.modIcon {
+			wnd.icon = request.props.Icon
+		}
+		request.props.styleSet(wnd.data, request.modIcon)
 	}
 	if request.modFullscreen {
 		C.g2d_window_fullscreen_set(wnd.data, &err1, &err2)
@@ -355,6 +908,19 @@ func (request *tSetPropertiesRequest) process() {
 		wnd.title = request.props.Title
 		C.g2d_window_title_set(wnd.data, t, ts, &err1, &err2)
 	}
+	if request.modWindowState {
+		var sn, sx C.int
+		if request.props.Minimized {
+			sn = 1
+		}
+		if request.props.Maximized {
+			sx = 1
+		}
+		C.g2d_window_state_set(wnd.data, sn, sx)
+	}
+	if request.modMonitor {
+		C.g2d_window_monitor_set(wnd.data, C.int(request.props.Monitor), &err1, &err2)
+	}
 	if request.modMouse {
 		C.g2d_mouse_pos_set(wnd.data, C.int(request.props.MouseX), C.int(request.props.MouseY), &err1, &err2)
 	}
@@ -376,44 +942,68 @@ func (request *tErrorRequest) process() {
 }
 
 func postRequest(request tRequest) {
-	var err1, err2 C.longlong
 	mutex.Lock()
+	appendRequest(request)
+	mutex.Unlock()
+}
+
+// postWndRequest posts request returned by newRequest, if window has
+// not been destroyed.
+func postWndRequest(wnd *WindowImpl, newRequest func(wndId int) tRequest) {
+	mutex.Lock()
+	if wnd.id >= 0 {
+		appendRequest(newRequest(wnd.id))
+	}
+	mutex.Unlock()
+}
+
+func appendRequest(request tRequest) {
+	var err1, err2 C.longlong
 	requests = append(requests, request)
 	C.g2d_post_request(&err1, &err2)
 	if err1 != 0 {
 		(&tErrorRequest{err: toError(err1, err2, nil)}).process()
 	}
-	mutex.Unlock()
 }
 
 func cleanUp() {
 	for _, wnd := range wnds {
 		if wnd != nil {
-			var err1, err2 C.longlong
-			if wnd.data != nil {
-				wnd.eventsChan <- &tLogicEvent{typeId: destroyType, err: Err, time: appTime.Millis()}
-				<-wnd.quittedChan
-				C.g2d_window_destroy(wnd.data, &err1, &err2)
-				wnd.data = nil
-				if err1 != 0 {
-					(&tErrorRequest{err: toError(err1, err2, nil)}).process()
-				}
-			} else {
-				wnd.eventsChan <- &tLogicEvent{typeId: leaveType, time: appTime.Millis()}
-				<-wnd.quittedChan
-			}
-			unregisterWnd(wnd.id).impl = nil
+			cleanUpWnd(wnd)
 		}
 	}
 	C.g2d_clean_up()
 }
 
+func cleanUpWnd(wnd *tWindow) {
+	var err1, err2 C.longlong
+	// owned windows are destroyed before their owner
+	for _, owned := range wnds {
+		if owned != nil && owned.owner == wnd && owned.data != nil {
+			cleanUpWnd(owned)
+		}
+	}
+	if wnd.data != nil {
+		wnd.eventsChan <- &tLogicEvent{typeId: destroyType, err: Err, time: appTime.Millis()}
+		<-wnd.quittedChan
+		C.g2d_window_destroy(wnd.data, &err1, &err2)
+		wnd.data = nil
+		if err1 != 0 {
+			(&tErrorRequest{err: toError(err1, err2, nil)}).process()
+		}
+	} else {
+		wnd.eventsChan <- &tLogicEvent{typeId: leaveType, time: appTime.Millis()}
+		<-wnd.quittedChan
+	}
+	unregisterWnd(wnd.id).impl = nil
+}
+
 func postLogicEvent(id C.int, event *tLogicEvent) {
 	if !processingRequests {
 		mutex.Lock()
 	}
 	wnd := wnds[id]
-	event.props.update(wnd.data, wnd.title)
+	event.props.update(wnd.data, wnd.title, wnd.icon)
 	wnd.eventsChan <- event
 	if !processingRequests {
 		mutex.Unlock()
@@ -435,6 +1025,8 @@ func g2dProcessRequest() {
 		}
 	}
 	requests = requests[:0]
+	wndNextId = append(wndNextId, wndIdsReleased...)
+	wndIdsReleased = wndIdsReleased[:0]
 	processingRequests = false
 	mutex.Unlock()
 }
@@ -459,6 +1051,26 @@ func g2dMouseMove(id C.int) {
 	postLogicEvent(id, &tLogicEvent{typeId: msMoveType, time: appTime.Millis()})
 }
 
+//export g2dMouseEnter
+func g2dMouseEnter(id C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: msEnterType, time: appTime.Millis()})
+}
+
+//export g2dMouseLeave
+func g2dMouseLeave(id C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: msLeaveType, time: appTime.Millis()})
+}
+
+//export g2dMonitorChanged
+func g2dMonitorChanged(id C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: monitorType, obj: Monitors(), time: appTime.Millis()})
+}
+
+//export g2dContentScale
+func g2dContentScale(id C.int, scale C.float) {
+	postLogicEvent(id, &tLogicEvent{typeId: scaleType, valC: float32(scale), time: appTime.Millis()})
+}
+
 //export g2dWindowMove
 func g2dWindowMove(id C.int) {
 	postLogicEvent(id, &tLogicEvent{typeId: wndMoveType, time: appTime.Millis()})
@@ -471,7 +1083,7 @@ func g2dWindowResize(id C.int) {
 	}
 	wnd := wnds[id]
 	event := &tLogicEvent{typeId: wndResizeType, time: appTime.Millis()}
-	event.props.update(wnd.data, wnd.title)
+	event.props.update(wnd.data, wnd.title, wnd.icon)
 	wnd.eventsChan <- event
 	if !processingRequests {
 		mutex.Unlock()
@@ -479,13 +1091,15 @@ func g2dWindowResize(id C.int) {
 }
 
 //export g2dButtonDown
-func g2dButtonDown(id, code, doubleClick C.int) {
-	postLogicEvent(id, &tLogicEvent{typeId: buttonDownType, valA: int(code), repeated: uint(doubleClick), time: appTime.Millis()})
+func g2dButtonDown(id, code, x, y, clicks, mods C.int) {
+	button := MouseButton{Code: int(code), X: int(x), Y: int(y), Clicks: int(clicks), Modifiers: int(mods)}
+	postLogicEvent(id, &tLogicEvent{typeId: buttonDownType, obj: button, time: appTime.Millis()})
 }
 
 //export g2dButtonUp
-func g2dButtonUp(id, code, doubleClick C.int) {
-	postLogicEvent(id, &tLogicEvent{typeId: buttonUpType, valA: int(code), repeated: uint(doubleClick), time: appTime.Millis()})
+func g2dButtonUp(id, code, x, y, clicks, mods C.int) {
+	button := MouseButton{Code: int(code), X: int(x), Y: int(y), Clicks: int(clicks), Modifiers: int(mods)}
+	postLogicEvent(id, &tLogicEvent{typeId: buttonUpType, obj: button, time: appTime.Millis()})
 }
 
 //export g2dWheel
@@ -493,11 +1107,29 @@ func g2dWheel(id C.int, wheel C.float) {
 	postLogicEvent(id, &tLogicEvent{typeId: wheelType, valC: float32(wheel), time: appTime.Millis()})
 }
 
+//export g2dTouch
+func g2dTouch(id, touchId, phase, x, y C.int, pressure C.float) {
+	touch := Touch{Id: int(touchId), Phase: int(phase), X: int(x), Y: int(y), Pressure: float32(pressure)}
+	postLogicEvent(id, &tLogicEvent{typeId: touchType, obj: touch, time: appTime.Millis()})
+}
+
+//export g2dPen
+func g2dPen(id, penId, phase, x, y C.int, pressure, tiltX, tiltY C.float, eraser, barrel C.int) {
+	pen := Pen{Id: int(penId), Phase: int(phase), X: int(x), Y: int(y), Pressure: float32(pressure)}
+	pen.TiltX, pen.TiltY, pen.Eraser, pen.Barrel = float32(tiltX), float32(tiltY), eraser != 0, barrel != 0
+	postLogicEvent(id, &tLogicEvent{typeId: penType, obj: pen, time: appTime.Millis()})
+}
+
 //export g2dWindowMinimize
 func g2dWindowMinimize(id C.int) {
 	postLogicEvent(id, &tLogicEvent{typeId: minimizeType, time: appTime.Millis()})
 }
 
+//export g2dWindowMaximize
+func g2dWindowMaximize(id C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: maximizeType, time: appTime.Millis()})
+}
+
 //export g2dWindowRestore
 func g2dWindowRestore(id C.int) {
 	postLogicEvent(id, &tLogicEvent{typeId: restoreType, time: appTime.Millis()})
@@ -508,6 +1140,32 @@ func g2dOnFocus(id, focus C.int) {
 	postLogicEvent(id, &tLogicEvent{typeId: focusType, valA: int(focus), time: appTime.Millis()})
 }
 
+//export g2dEndSession
+func g2dEndSession(id C.int) {
+	// session ends after WM_QUERYENDSESSION, i.e. wait for OnEndSession
+	done := make(chan bool, 1)
+	postLogicEvent(id, &tLogicEvent{typeId: endSessionType, obj: done, time: appTime.Millis()})
+	select {
+	case <-done:
+	case <-time.After(endSessionTimeout):
+	}
+}
+
+//export g2dSuspend
+func g2dSuspend(id C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: suspendType, time: appTime.Millis()})
+}
+
+//export g2dResume
+func g2dResume(id C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: resumeType, time: appTime.Millis()})
+}
+
+//export g2dOccluded
+func g2dOccluded(id, occluded C.int) {
+	postLogicEvent(id, &tLogicEvent{typeId: occludedType, valA: int(occluded), time: appTime.Millis()})
+}
+
 func toError(err1, err2 C.longlong, errInfo *C.char) error {
 	var err error
 	if err1 > 0 {
@@ -584,6 +1242,12 @@ func toError(err1, err2 C.longlong, errInfo *C.char) error {
 					errStr = fmt.Sprintf(functionFailedWindow, "set title")
 				case 1001022:
 					errStr = fmt.Sprintf(functionFailedG2D, "set mouse position")
+				case 1001023:
+					errStr = fmt.Sprintf(functionFailedWindow, "move to monitor")
+				case 1001024:
+					errStr = fmt.Sprintf(functionFailedWindow, "change display mode")
+				case 1001025:
+					errStr = fmt.Sprintf(functionFailedWindow, "set fullscreen")
 				}
 			}
 		} else {
@@ -708,6 +1372,10 @@ func toError(err1, err2 C.longlong, errInfo *C.char) error {
 				errStr = fmt.Sprintf(functionFailedG2D, "load texture")
 			case 1002060:
 				errStr = fmt.Sprintf(functionFailedG2D, "load texture")
+			case 1002061:
+				errStr = fmt.Sprintf(functionFailedG2D, "create framebuffer")
+			case 1002062:
+				errStr = fmt.Sprintf(functionFailedG2D, "capture frame")
 			}
 		}
 		if len(errStr) == 0 {
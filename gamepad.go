@@ -0,0 +1,582 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Buttons of the standard gamepad layout.
+const (
+	GamepadA = iota
+	GamepadB
+	GamepadX
+	GamepadY
+	GamepadBack
+	GamepadGuide
+	GamepadStart
+	GamepadLeftStick
+	GamepadRightStick
+	GamepadLeftShoulder
+	GamepadRightShoulder
+	GamepadDPadUp
+	GamepadDPadDown
+	GamepadDPadLeft
+	GamepadDPadRight
+	GamepadButtons
+)
+
+// Axes of the standard gamepad layout. Sticks are in range of [-1, 1]
+// (down and right are positive), triggers in range of [0, 1].
+const (
+	GamepadLeftX = iota
+	GamepadLeftY
+	GamepadRightX
+	GamepadRightY
+	GamepadLeftTrigger
+	GamepadRightTrigger
+	GamepadAxes
+)
+
+const (
+	gamepadPollInterval = 8 * time.Millisecond
+	gamepadScanInterval = 1000 * time.Millisecond
+)
+
+const (
+	gamepadInputButton = iota
+	gamepadInputAxis
+	gamepadInputHat
+)
+
+var (
+	gamepadMutex       sync.Mutex
+	gamepads           []*tGamepad
+	gamepadMappings    = make(map[string]*tGamepadMapping)
+	gamepadDeadzone    = float32(0.15)
+	gamepadQuitChan    chan bool
+	gamepadQuittedChan chan bool
+)
+
+// Gamepad is the state of a game controller in the standard layout.
+type Gamepad struct {
+	Id        int
+	Name      string
+	Buttons   [GamepadButtons]bool
+	Axes      [GamepadAxes]float32
+	Connected bool
+}
+
+type tGamepad struct {
+	state   Gamepad
+	raw     tGamepadRaw
+	mapping *tGamepadMapping
+	device  tGamepadDevice
+}
+
+// tGamepadRaw is the unmapped state of a device. Axes are in range of [-1, 1],
+// hats are bitmasks (1 up, 2 right, 4 down, 8 left).
+type tGamepadRaw struct {
+	buttons []bool
+	axes    []float32
+	hats    []int
+}
+
+type tGamepadDevice interface {
+	poll(raw *tGamepadRaw) bool
+	info() (string, string)
+	mapping() *tGamepadMapping
+	close()
+}
+
+type tGamepadMapping struct {
+	guid, name string
+	bindings   []tGamepadBinding
+}
+
+type tGamepadBinding struct {
+	input, index, hatMask int
+	inHalf                int
+	invert                bool
+	output                int
+	isAxis                bool
+	outHalf               int
+}
+
+// AddGamepadMappings adds mappings in the format of SDL's gamecontrollerdb.txt
+// (one mapping per line). Empty lines, comments and mappings for other platforms
+// are skipped.
+func AddGamepadMappings(mappings string) error {
+	var parsed []*tGamepadMapping
+	for i, line := range strings.Split(mappings, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' {
+			mapping, err := parseGamepadMapping(line)
+			if err != nil {
+				return fmt.Errorf("gamepad mapping in line %d: %s", i+1, err.Error())
+			}
+			if mapping != nil {
+				parsed = append(parsed, mapping)
+			}
+		}
+	}
+	gamepadMutex.Lock()
+	for _, mapping := range parsed {
+		gamepadMappings[mapping.guid] = mapping
+	}
+	gamepadMutex.Unlock()
+	return nil
+}
+
+// SetGamepadDeadzone sets the deadzone for sticks and triggers. Deadzone must
+// be in range of [0, 1). Default is 0.15.
+func SetGamepadDeadzone(deadzone float32) {
+	if deadzone >= 0 && deadzone < 1 {
+		gamepadMutex.Lock()
+		gamepadDeadzone = deadzone
+		gamepadMutex.Unlock()
+	} else {
+		panic(fmt.Sprintf("invalid gamepad deadzone (%f)", deadzone))
+	}
+}
+
+func parseGamepadMapping(str string) (*tGamepadMapping, error) {
+	fields := strings.Split(str, ",")
+	if len(fields) < 2 || len(fields[0]) == 0 {
+		return nil, errors.New("guid or name missing")
+	}
+	mapping := &tGamepadMapping{guid: gamepadGUIDNormalized(fields[0]), name: fields[1]}
+	for _, field := range fields[2:] {
+		if len(field) > 0 {
+			keyValue := strings.SplitN(field, ":", 2)
+			if len(keyValue) != 2 {
+				return nil, fmt.Errorf("invalid field \"%s\"", field)
+			}
+			key, value := keyValue[0], keyValue[1]
+			if key == "platform" {
+				if value != gamepadPlatform() {
+					return nil, nil
+				}
+			} else {
+				binding := tGamepadBinding{output: -1}
+				if len(key) > 0 && (key[0] == '+' || key[0] == '-') {
+					binding.outHalf = halfFromSign(key[0])
+					key = key[1:]
+				}
+				binding.output, binding.isAxis = gamepadOutput(key)
+				if binding.output >= 0 {
+					if err := binding.parseInput(value); err == nil {
+						mapping.bindings = append(mapping.bindings, binding)
+					} else {
+						return nil, fmt.Errorf("invalid value of \"%s\": %s", key, err.Error())
+					}
+				}
+			}
+		}
+	}
+	return mapping, nil
+}
+
+func (binding *tGamepadBinding) parseInput(value string) error {
+	var err error
+	if len(value) > 0 && (value[0] == '+' || value[0] == '-') {
+		binding.inHalf = halfFromSign(value[0])
+		value = value[1:]
+	}
+	if len(value) > 0 && value[len(value)-1] == '~' {
+		binding.invert = true
+		value = value[:len(value)-1]
+	}
+	if len(value) > 1 {
+		switch value[0] {
+		case 'b':
+			binding.input = gamepadInputButton
+			binding.index, err = strconv.Atoi(value[1:])
+		case 'a':
+			binding.input = gamepadInputAxis
+			binding.index, err = strconv.Atoi(value[1:])
+		case 'h':
+			hat := strings.SplitN(value[1:], ".", 2)
+			if len(hat) == 2 {
+				binding.input = gamepadInputHat
+				binding.index, err = strconv.Atoi(hat[0])
+				if err == nil {
+					binding.hatMask, err = strconv.Atoi(hat[1])
+				}
+			} else {
+				err = errors.New("hat mask missing")
+			}
+		default:
+			err = errors.New("unknown input")
+		}
+	} else {
+		err = errors.New("input missing")
+	}
+	if err == nil && binding.index < 0 {
+		err = errors.New("negative index")
+	}
+	return err
+}
+
+func (mapping *tGamepadMapping) apply(raw *tGamepadRaw, buttons *[GamepadButtons]bool, axes *[GamepadAxes]float32) {
+	*buttons = [GamepadButtons]bool{}
+	*axes = [GamepadAxes]float32{}
+	for _, binding := range mapping.bindings {
+		value, available := binding.inputValue(raw)
+		if available {
+			if binding.isAxis {
+				value = binding.axisValue(value)
+				if abs32(value) > abs32(axes[binding.output]) {
+					axes[binding.output] = value
+				}
+			} else if value > 0.5 {
+				buttons[binding.output] = true
+			}
+		}
+	}
+}
+
+func (binding *tGamepadBinding) inputValue(raw *tGamepadRaw) (float32, bool) {
+	var value float32
+	switch binding.input {
+	case gamepadInputButton:
+		if binding.index >= len(raw.buttons) {
+			return 0, false
+		} else if raw.buttons[binding.index] {
+			value = 1
+		}
+	case gamepadInputHat:
+		if binding.index >= len(raw.hats) {
+			return 0, false
+		} else if raw.hats[binding.index]&binding.hatMask != 0 {
+			value = 1
+		}
+	case gamepadInputAxis:
+		if binding.index >= len(raw.axes) {
+			return 0, false
+		}
+		value = raw.axes[binding.index]
+		if binding.invert {
+			value = -value
+		}
+		if binding.inHalf > 0 {
+			value = max32(value, 0)
+		} else if binding.inHalf < 0 {
+			value = max32(-value, 0)
+		}
+	}
+	return value, true
+}
+
+func (binding *tGamepadBinding) axisValue(value float32) float32 {
+	trigger := bool(binding.output == GamepadLeftTrigger || binding.output == GamepadRightTrigger)
+	if binding.outHalf != 0 {
+		// button or half axis to half of output
+		if binding.input == gamepadInputAxis && binding.inHalf == 0 {
+			value = (value + 1) / 2
+		}
+		return value * float32(binding.outHalf)
+	}
+	if binding.input == gamepadInputAxis && binding.inHalf == 0 {
+		if trigger {
+			return (value + 1) / 2
+		}
+		return value
+	}
+	if trigger {
+		return value
+	}
+	// button or half axis to full output
+	return value*2 - 1
+}
+
+// update polls device and returns events for all changes.
+func (pad *tGamepad) update(deadzone float32) []*tLogicEvent {
+	var events []*tLogicEvent
+	var buttons [GamepadButtons]bool
+	var axes [GamepadAxes]float32
+	connected := pad.device.poll(&pad.raw)
+	if connected {
+		if pad.mapping != nil {
+			pad.mapping.apply(&pad.raw, &buttons, &axes)
+			applyDeadzone(&axes, deadzone)
+		}
+		if !pad.state.Connected {
+			pad.state.Connected = true
+			events = append(events, &tLogicEvent{typeId: gamepadConnType, valA: pad.state.Id, valB: 1})
+		}
+	}
+	for i, pressed := range buttons {
+		if pressed != pad.state.Buttons[i] {
+			pad.state.Buttons[i] = pressed
+			events = append(events, &tLogicEvent{typeId: gamepadButtonType, valA: pad.state.Id, valB: i, valC: boolToFloat32(pressed)})
+		}
+	}
+	for i, value := range axes {
+		if value != pad.state.Axes[i] {
+			pad.state.Axes[i] = value
+			events = append(events, &tLogicEvent{typeId: gamepadAxisType, valA: pad.state.Id, valB: i, valC: value})
+		}
+	}
+	if !connected && pad.state.Connected {
+		pad.state.Connected = false
+		events = append(events, &tLogicEvent{typeId: gamepadConnType, valA: pad.state.Id, valB: 0})
+	}
+	return events
+}
+
+func gamepadThread() {
+	var sinceScan time.Duration
+	ticker := time.NewTicker(gamepadPollInterval)
+	defer ticker.Stop()
+	gamepadsScan()
+	for {
+		select {
+		case <-gamepadQuitChan:
+			gamepadMutex.Lock()
+			for _, pad := range gamepads {
+				pad.device.close()
+			}
+			gamepads = nil
+			gamepadMutex.Unlock()
+			gamepadQuittedChan <- true
+			return
+		case <-ticker.C:
+			sinceScan += gamepadPollInterval
+			if sinceScan >= gamepadScanInterval {
+				sinceScan = 0
+				gamepadsScan()
+			}
+			gamepadsPoll()
+		}
+	}
+}
+
+func gamepadsScan() {
+	gamepadMutex.Lock()
+	devices := make([]tGamepadDevice, 0, len(gamepads))
+	for _, pad := range gamepads {
+		devices = append(devices, pad.device)
+	}
+	for _, device := range gamepadDevicesScan(devices) {
+		guid, name := device.info()
+		pad := &tGamepad{device: device, mapping: gamepadMappings[gamepadGUIDNormalized(guid)]}
+		if pad.mapping == nil {
+			pad.mapping = device.mapping()
+		}
+		pad.state.Id = gamepadNextId()
+		pad.state.Name = name
+		gamepads = append(gamepads, pad)
+	}
+	gamepadMutex.Unlock()
+}
+
+func gamepadsPoll() {
+	var events []*tLogicEvent
+	gamepadMutex.Lock()
+	for i := 0; i < len(gamepads); i++ {
+		pad := gamepads[i]
+		events = append(events, pad.update(gamepadDeadzone)...)
+		if !pad.state.Connected {
+			pad.device.close()
+			gamepads = append(gamepads[:i], gamepads[i+1:]...)
+			i--
+		}
+	}
+	gamepadMutex.Unlock()
+	for _, event := range events {
+		postGamepadEvent(event)
+	}
+}
+
+func gamepadsStart() {
+	gamepadQuitChan = make(chan bool, 1)
+	gamepadQuittedChan = make(chan bool, 1)
+	go gamepadThread()
+}
+
+func gamepadsStop() {
+	gamepadQuitChan <- true
+	<-gamepadQuittedChan
+}
+
+// gamepadsSnapshot returns states of connected gamepads.
+func gamepadsSnapshot() []Gamepad {
+	gamepadMutex.Lock()
+	states := make([]Gamepad, 0, len(gamepads))
+	for _, pad := range gamepads {
+		if pad.state.Connected {
+			states = append(states, pad.state)
+		}
+	}
+	gamepadMutex.Unlock()
+	return states
+}
+
+func gamepadNextId() int {
+	for id := 0; ; id++ {
+		var used bool
+		for _, pad := range gamepads {
+			if pad.state.Id == id {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return id
+		}
+	}
+}
+
+func postGamepadEvent(event *tLogicEvent) {
+	mutex.Lock()
+	for _, wnd := range wnds {
+		if wnd != nil && wnd.data != nil {
+			wndEvent := *event
+			wndEvent.time = appTime.Millis()
+			wndEvent.props.update(wnd.data, wnd.title, wnd.icon)
+			wnd.eventsChan <- &wndEvent
+		}
+	}
+	mutex.Unlock()
+}
+
+func applyDeadzone(axes *[GamepadAxes]float32, deadzone float32) {
+	for _, stick := range [][2]int{{GamepadLeftX, GamepadLeftY}, {GamepadRightX, GamepadRightY}} {
+		x, y := axes[stick[0]], axes[stick[1]]
+		length := float32(math.Hypot(float64(x), float64(y)))
+		if length <= deadzone {
+			axes[stick[0]], axes[stick[1]] = 0, 0
+		} else {
+			scale := min32((length-deadzone)/(1-deadzone), 1) / length
+			axes[stick[0]], axes[stick[1]] = x*scale, y*scale
+		}
+	}
+	for _, trigger := range []int{GamepadLeftTrigger, GamepadRightTrigger} {
+		if axes[trigger] <= deadzone {
+			axes[trigger] = 0
+		} else {
+			axes[trigger] = min32((axes[trigger]-deadzone)/(1-deadzone), 1)
+		}
+	}
+}
+
+// gamepadGUIDNormalized removes CRC from GUID (newer SDL versions).
+func gamepadGUIDNormalized(guid string) string {
+	guid = strings.ToLower(guid)
+	if len(guid) == 32 {
+		guid = guid[:4] + "0000" + guid[8:]
+	}
+	return guid
+}
+
+func gamepadOutput(name string) (int, bool) {
+	switch name {
+	case "a":
+		return GamepadA, false
+	case "b":
+		return GamepadB, false
+	case "x":
+		return GamepadX, false
+	case "y":
+		return GamepadY, false
+	case "back":
+		return GamepadBack, false
+	case "guide":
+		return GamepadGuide, false
+	case "start":
+		return GamepadStart, false
+	case "leftstick":
+		return GamepadLeftStick, false
+	case "rightstick":
+		return GamepadRightStick, false
+	case "leftshoulder":
+		return GamepadLeftShoulder, false
+	case "rightshoulder":
+		return GamepadRightShoulder, false
+	case "dpup":
+		return GamepadDPadUp, false
+	case "dpdown":
+		return GamepadDPadDown, false
+	case "dpleft":
+		return GamepadDPadLeft, false
+	case "dpright":
+		return GamepadDPadRight, false
+	case "leftx":
+		return GamepadLeftX, true
+	case "lefty":
+		return GamepadLeftY, true
+	case "rightx":
+		return GamepadRightX, true
+	case "righty":
+		return GamepadRightY, true
+	case "lefttrigger":
+		return GamepadLeftTrigger, true
+	case "righttrigger":
+		return GamepadRightTrigger, true
+	}
+	return -1, false
+}
+
+func gamepadPlatform() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "Mac OS X"
+	case "linux":
+		return "Linux"
+	case "android":
+		return "Android"
+	case "ios":
+		return "iOS"
+	}
+	return runtime.GOOS
+}
+
+func halfFromSign(sign byte) int {
+	if sign == '+' {
+		return 1
+	}
+	return -1
+}
+
+func boolToFloat32(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func abs32(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
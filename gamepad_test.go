@@ -0,0 +1,95 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"testing"
+)
+
+type tFakeDevice struct {
+	raw       tGamepadRaw
+	connected bool
+}
+
+func (dev *tFakeDevice) poll(raw *tGamepadRaw) bool {
+	*raw = dev.raw
+	return dev.connected
+}
+
+func (dev *tFakeDevice) info() (string, string) {
+	return "fake", "Fake Device"
+}
+
+func (dev *tFakeDevice) mapping() *tGamepadMapping {
+	return nil
+}
+
+func (dev *tFakeDevice) close() {
+}
+
+func TestParseGamepadMapping(t *testing.T) {
+	mapping, err := parseGamepadMapping("03000000de280000ff11000001000000,Steam Virtual Gamepad,a:b0,b:b1,dpup:h0.1,leftx:a0,lefttrigger:a2,-lefty:b3,righty:a4~,misc1:b9,")
+	if err != nil {
+		t.Error(err.Error())
+	} else if mapping.guid != "03000000de280000ff11000001000000" || mapping.name != "Steam Virtual Gamepad" {
+		t.Error("guid or name not parsed")
+	} else if len(mapping.bindings) != 7 {
+		t.Error("bindings", len(mapping.bindings))
+	} else {
+		var buttons [GamepadButtons]bool
+		var axes [GamepadAxes]float32
+		raw := tGamepadRaw{buttons: []bool{true, false, false, true}, axes: []float32{0.5, 0, -1, 0, 0.25}, hats: []int{1}}
+		mapping.apply(&raw, &buttons, &axes)
+		if !buttons[GamepadA] || buttons[GamepadB] || !buttons[GamepadDPadUp] {
+			t.Error("buttons", buttons)
+		}
+		if axes[GamepadLeftX] != 0.5 || axes[GamepadLeftTrigger] != 0 || axes[GamepadLeftY] != -1 || axes[GamepadRightY] != -0.25 {
+			t.Error("axes", axes)
+		}
+	}
+	_, err = parseGamepadMapping("xinput,XInput,a:c0,")
+	if err == nil {
+		t.Error("invalid input not detected")
+	}
+}
+
+func TestGamepadDeadzone(t *testing.T) {
+	axes := [GamepadAxes]float32{0.1, 0.1, 1, 0, 0.1, 1}
+	applyDeadzone(&axes, 0.2)
+	if axes[GamepadLeftX] != 0 || axes[GamepadLeftY] != 0 || axes[GamepadLeftTrigger] != 0 {
+		t.Error("deadzone not applied", axes)
+	}
+	if axes[GamepadRightX] != 1 || axes[GamepadRightTrigger] != 1 {
+		t.Error("value not rescaled", axes)
+	}
+}
+
+func TestGamepadUpdate(t *testing.T) {
+	mapping, _ := parseGamepadMapping("fake,Fake Device,a:b0,leftx:a0,")
+	dev := &tFakeDevice{connected: true, raw: tGamepadRaw{buttons: []bool{true}, axes: []float32{0.5}}}
+	pad := &tGamepad{device: dev, mapping: mapping}
+	events := pad.update(0)
+	if len(events) != 3 || events[0].typeId != gamepadConnType || events[1].typeId != gamepadButtonType || events[2].typeId != gamepadAxisType {
+		t.Error("connect events", len(events))
+	}
+	if events = pad.update(0); len(events) != 0 {
+		t.Error("events without change", len(events))
+	}
+	dev.connected = false
+	events = pad.update(0)
+	if len(events) != 3 || events[2].typeId != gamepadConnType || events[2].valB != 0 || pad.state.Connected {
+		t.Error("disconnect events", len(events))
+	}
+}
+
+func TestGamepadGUID(t *testing.T) {
+	guid := "030000005e0400008e02000014010000"
+	if gamepadGUIDNormalized("0300AB125E0400008E02000014010000") != guid {
+		t.Error("CRC not removed")
+	}
+}
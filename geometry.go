@@ -0,0 +1,169 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+)
+
+// Geometry is position, size and state of a window, that can be saved
+// and restored between runs. Position is relative to monitor, size is
+// in logical units.
+type Geometry struct {
+	Monitor      int    `json:"monitor"`
+	MonitorName  string `json:"monitor_name"`
+	ClientX      int    `json:"client_x"`
+	ClientY      int    `json:"client_y"`
+	ClientWidth  int    `json:"client_width"`
+	ClientHeight int    `json:"client_height"`
+	Maximized    bool   `json:"maximized"`
+	Fullscreen   bool   `json:"fullscreen"`
+}
+
+// SaveGeometry writes geometry of window with properties props to file
+// (JSON). Call it in OnClose.
+func SaveGeometry(path string, props *Properties) error {
+	return NewGeometry(props, Monitors()).Save(path)
+}
+
+// RestoreGeometry reads geometry from file and applies it to config.
+// Missing file is not an error. Call it in OnConfig.
+func RestoreGeometry(path string, config *Configuration) error {
+	geometry, err := LoadGeometry(path)
+	if err == nil {
+		geometry.Apply(config, Monitors())
+	} else if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return err
+}
+
+// NewGeometry returns geometry of window with properties props. Position
+// and size are those of the window, when it is neither maximized nor
+// fullscreen.
+func NewGeometry(props *Properties, monitors []Monitor) *Geometry {
+	geometry := new(Geometry)
+	scale := props.ContentScale
+	if scale <= 0 {
+		scale = 1
+	}
+	geometry.Monitor = props.Monitor
+	geometry.ClientX, geometry.ClientY = props.NormalX, props.NormalY
+	if props.Monitor >= 0 && props.Monitor < len(monitors) {
+		monitor := &monitors[props.Monitor]
+		geometry.MonitorName = monitor.Name
+		geometry.ClientX, geometry.ClientY = props.NormalX-monitor.X, props.NormalY-monitor.Y
+	}
+	geometry.ClientWidth = int(math.Round(float64(float32(props.NormalWidth) / scale)))
+	geometry.ClientHeight = int(math.Round(float64(float32(props.NormalHeight) / scale)))
+	geometry.Maximized, geometry.Fullscreen = props.Maximized, props.Fullscreen
+	return geometry
+}
+
+// LoadGeometry reads geometry from file (JSON).
+func LoadGeometry(path string) (*Geometry, error) {
+	bytes, err := os.ReadFile(path)
+	if err == nil {
+		geometry := new(Geometry)
+		err = json.Unmarshal(bytes, geometry)
+		if err == nil {
+			return geometry, nil
+		}
+	}
+	return nil, err
+}
+
+// Save writes geometry to file (JSON).
+func (geometry *Geometry) Save(path string) error {
+	bytes, err := json.MarshalIndent(geometry, "", "\t")
+	if err == nil {
+		err = os.WriteFile(path, bytes, 0644)
+	}
+	return err
+}
+
+// Apply sets geometry to config. Window is clamped to the work area of
+// its monitor. If monitor is not attached anymore, window is centered
+// on primary monitor.
+func (geometry *Geometry) Apply(config *Configuration, monitors []Monitor) {
+	index, found := geometry.monitorIndex(monitors)
+	config.Maximized, config.Fullscreen = geometry.Maximized, geometry.Fullscreen
+	if geometry.ClientWidth > 0 && geometry.ClientHeight > 0 {
+		config.ClientWidth, config.ClientHeight = geometry.ClientWidth, geometry.ClientHeight
+	}
+	if index >= 0 {
+		monitor := &monitors[index]
+		scale := monitor.Scale
+		if scale <= 0 {
+			scale = 1
+		}
+		// work area relative to monitor
+		workX, workY := monitor.WorkX-monitor.X, monitor.WorkY-monitor.Y
+		workWidth, workHeight := monitor.WorkWidth, monitor.WorkHeight
+		if workWidth <= 0 || workHeight <= 0 {
+			workX, workY, workWidth, workHeight = 0, 0, monitor.Width, monitor.Height
+		}
+		config.ClientWidth = minInt(config.ClientWidth, int(float32(workWidth)/scale))
+		config.ClientHeight = minInt(config.ClientHeight, int(float32(workHeight)/scale))
+		config.Monitor = index
+		if found {
+			width, height := int(float32(config.ClientWidth)*scale), int(float32(config.ClientHeight)*scale)
+			config.ClientX = monitor.X + maxInt(workX, minInt(geometry.ClientX, workX+workWidth-width))
+			config.ClientY = monitor.Y + maxInt(workY, minInt(geometry.ClientY, workY+workHeight-height))
+			config.Centered = false
+		} else {
+			config.Centered = true
+		}
+	}
+}
+
+// monitorIndex returns index of monitor by name (or by index, if name
+// is not set). If not found, returns primary monitor. Returns -1, if
+// there are no monitors.
+func (geometry *Geometry) monitorIndex(monitors []Monitor) (int, bool) {
+	if len(monitors) > 0 {
+		if len(geometry.MonitorName) > 0 {
+			// prefer same index, if names are ambiguous
+			if geometry.Monitor >= 0 && geometry.Monitor < len(monitors) && monitors[geometry.Monitor].Name == geometry.MonitorName {
+				return geometry.Monitor, true
+			}
+			for i := range monitors {
+				if monitors[i].Name == geometry.MonitorName {
+					return i, true
+				}
+			}
+		} else if geometry.Monitor >= 0 && geometry.Monitor < len(monitors) {
+			return geometry.Monitor, true
+		}
+		for i := range monitors {
+			if monitors[i].Primary {
+				return i, false
+			}
+		}
+		return 0, false
+	}
+	return -1, false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,72 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+var testMonitors = []Monitor{
+	{Name: "A", Width: 1920, Height: 1080, WorkWidth: 1920, WorkHeight: 1040, Scale: 1, Primary: true},
+	{Name: "B", X: 1920, Width: 2560, Height: 1440, WorkX: 1920, WorkWidth: 2560, WorkHeight: 1400, Scale: 2},
+}
+
+func TestNewGeometry(t *testing.T) {
+	props := Properties{Monitor: 1, ClientX: 1920, ClientY: 0, ClientWidth: 2560, ClientHeight: 1400, ContentScale: 2, Maximized: true}
+	props.NormalX, props.NormalY, props.NormalWidth, props.NormalHeight = 2020, 50, 1280, 960
+	geometry := NewGeometry(&props, testMonitors)
+	if geometry.MonitorName != "B" || geometry.ClientX != 100 || geometry.ClientY != 50 {
+		t.Error("position is", geometry.MonitorName, geometry.ClientX, geometry.ClientY)
+	}
+	if geometry.ClientWidth != 640 || geometry.ClientHeight != 480 || !geometry.Maximized {
+		t.Error("size is", geometry.ClientWidth, geometry.ClientHeight, geometry.Maximized)
+	}
+}
+
+func TestGeometryApply(t *testing.T) {
+	config := newConfiguration()
+	geometry := &Geometry{Monitor: 0, MonitorName: "B", ClientX: 2500, ClientY: -20, ClientWidth: 800, ClientHeight: 600}
+	geometry.Apply(config, testMonitors)
+	if config.Monitor != 1 || config.Centered {
+		t.Error("monitor is", config.Monitor, config.Centered)
+	}
+	if config.ClientX != 1920+2560-1600 || config.ClientY != 0 {
+		t.Error("position not clamped", config.ClientX, config.ClientY)
+	}
+	config = newConfiguration()
+	geometry = &Geometry{Monitor: 1, MonitorName: "C", ClientX: 10, ClientY: 10, ClientWidth: 4000, ClientHeight: 600}
+	geometry.Apply(config, testMonitors)
+	if config.Monitor != 0 || !config.Centered {
+		t.Error("missing monitor not replaced by primary", config.Monitor, config.Centered)
+	}
+	if config.ClientWidth != 1920 || config.ClientHeight != 600 {
+		t.Error("size not clamped", config.ClientWidth, config.ClientHeight)
+	}
+}
+
+func TestGeometrySaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geometry.json")
+	geometry := &Geometry{Monitor: 1, MonitorName: "B", ClientX: 1, ClientY: 2, ClientWidth: 3, ClientHeight: 4, Fullscreen: true}
+	err := geometry.Save(path)
+	if err == nil {
+		var loaded *Geometry
+		loaded, err = LoadGeometry(path)
+		if err == nil && *loaded != *geometry {
+			t.Error("loaded geometry is", *loaded)
+		}
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	config := newConfiguration()
+	err = RestoreGeometry(filepath.Join(t.TempDir(), "missing.json"), config)
+	if err != nil || config.ClientWidth != 640 {
+		t.Error("missing file not ignored", err)
+	}
+}
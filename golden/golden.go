@@ -0,0 +1,181 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+// Package golden compares rendered frames (e.g. from g2d's Capture)
+// against reference PNG images for regression tests. Run tests with
+// -update-goldens to write the rendered frames as new references.
+package golden
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// maximum of squared YIQ difference
+const yiqDeltaMax = 35215.0
+
+// Update is set by command line flag -update-goldens. If true, golden
+// images are overwritten instead of compared.
+var Update = flag.Bool("update-goldens", false, "write rendered images as new golden images")
+
+// Options control the comparison. A pixel differs, if one of its
+// channels differs by more than Tolerance (0-255) and its perceptual
+// difference (in range [0, 1]) is greater than Threshold. Comparison
+// fails, if more than MaxDiffPixels pixels differ. Diff image is
+// written to DiffPath, or next to golden image with suffix "_diff".
+type Options struct {
+	Tolerance     int
+	Threshold     float64
+	MaxDiffPixels int
+	DiffPath      string
+}
+
+// Result is the result of Compare. Diff shows differing pixels red on
+// a faded copy of the image.
+type Result struct {
+	DiffPixels int
+	Diff       *image.RGBA
+}
+
+// Assert calls Check and reports the error to t.
+func Assert(t testing.TB, img image.Image, path string, options *Options) {
+	t.Helper()
+	if err := Check(img, path, options); err != nil {
+		t.Error(err)
+	}
+}
+
+// Check compares img with golden image from file path. If Update is
+// true, img is written to path instead. On failure the diff image is
+// written.
+func Check(img image.Image, path string, options *Options) error {
+	var err error
+	if options == nil {
+		options = new(Options)
+	}
+	if *Update {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = savePNG(path, img)
+		}
+	} else {
+		var golden image.Image
+		golden, err = loadPNG(path)
+		if err == nil {
+			var result Result
+			result, err = Compare(img, golden, options)
+			if err == nil && result.DiffPixels > options.MaxDiffPixels {
+				diffPath := options.DiffPath
+				if len(diffPath) == 0 {
+					diffPath = strings.TrimSuffix(path, filepath.Ext(path)) + "_diff.png"
+				}
+				err = savePNG(diffPath, result.Diff)
+				if err == nil {
+					err = fmt.Errorf("%s: %d pixels differ (diff image %s)", path, result.DiffPixels, diffPath)
+				}
+			}
+		} else if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%s: golden image missing (run with -update-goldens)", path)
+		}
+	}
+	return err
+}
+
+// Compare compares img with golden pixel by pixel.
+func Compare(img, golden image.Image, options *Options) (Result, error) {
+	var result Result
+	var err error
+	bounds, boundsGolden := img.Bounds(), golden.Bounds()
+	if bounds.Dx() == boundsGolden.Dx() && bounds.Dy() == boundsGolden.Dy() {
+		if options == nil {
+			options = new(Options)
+		}
+		result.Diff = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		for y := 0; y < bounds.Dy(); y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+				cGolden := color.NRGBAModel.Convert(golden.At(boundsGolden.Min.X+x, boundsGolden.Min.Y+y)).(color.NRGBA)
+				if channelsDiffer(c, cGolden, options.Tolerance) && perceptualDiff(c, cGolden) > options.Threshold {
+					result.DiffPixels++
+					result.Diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				} else {
+					gray := uint8(255 - (255-int(luma(c)))/4)
+					result.Diff.SetRGBA(x, y, color.RGBA{gray, gray, gray, 255})
+				}
+			}
+		}
+	} else {
+		err = fmt.Errorf("size %dx%d differs from golden image size %dx%d", bounds.Dx(), bounds.Dy(), boundsGolden.Dx(), boundsGolden.Dy())
+	}
+	return result, err
+}
+
+func channelsDiffer(a, b color.NRGBA, tolerance int) bool {
+	return absDiff(a.R, b.R) > tolerance || absDiff(a.G, b.G) > tolerance || absDiff(a.B, b.B) > tolerance || absDiff(a.A, b.A) > tolerance
+}
+
+// perceptualDiff returns the YIQ difference of colors blended with white.
+func perceptualDiff(a, b color.NRGBA) float64 {
+	yA, iA, qA := yiq(a)
+	yB, iB, qB := yiq(b)
+	y, i, q := yA-yB, iA-iB, qA-qB
+	return math.Sqrt((0.5053*y*y + 0.299*i*i + 0.1957*q*q) / yiqDeltaMax)
+}
+
+func yiq(c color.NRGBA) (float64, float64, float64) {
+	alpha := float64(c.A) / 255
+	r := 255 + (float64(c.R)-255)*alpha
+	g := 255 + (float64(c.G)-255)*alpha
+	b := 255 + (float64(c.B)-255)*alpha
+	y := r*0.29889531 + g*0.58662247 + b*0.11448223
+	i := r*0.59597799 - g*0.27417610 - b*0.32180189
+	q := r*0.21147017 - g*0.52261711 + b*0.31114694
+	return y, i, q
+}
+
+func luma(c color.NRGBA) uint8 {
+	y, _, _ := yiq(c)
+	return uint8(math.Max(0, math.Min(255, y)))
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func loadPNG(path string) (image.Image, error) {
+	var img image.Image
+	file, err := os.Open(path)
+	if err == nil {
+		defer file.Close()
+		img, err = png.Decode(file)
+	}
+	return img, err
+}
+
+func savePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err == nil {
+		err = png.Encode(file, img)
+		errClose := file.Close()
+		if err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
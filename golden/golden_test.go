@@ -0,0 +1,70 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package golden
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	img, golden := newImage(color.RGBA{100, 100, 100, 255}), newImage(color.RGBA{100, 100, 100, 255})
+	img.SetRGBA(1, 1, color.RGBA{103, 100, 100, 255})
+	img.SetRGBA(2, 2, color.RGBA{200, 100, 100, 255})
+	result, err := Compare(img, golden, &Options{Tolerance: 5})
+	if err != nil || result.DiffPixels != 1 {
+		t.Error("diff pixels", result.DiffPixels, err)
+	} else if result.Diff.RGBAAt(2, 2) != (color.RGBA{255, 0, 0, 255}) {
+		t.Error("diff not marked", result.Diff.RGBAAt(2, 2))
+	}
+	result, err = Compare(img, golden, &Options{Threshold: 0.5})
+	if err != nil || result.DiffPixels != 0 {
+		t.Error("perceptual threshold ignored", result.DiffPixels, err)
+	}
+	_, err = Compare(img, image.NewRGBA(image.Rect(0, 0, 2, 2)), nil)
+	if err == nil {
+		t.Error("size difference not detected")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "a.png")
+	img := newImage(color.RGBA{0, 0, 255, 255})
+	if err := Check(img, path, nil); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Error("missing golden image not reported", err)
+	}
+	*Update = true
+	err := Check(img, path, nil)
+	*Update = false
+	if err == nil {
+		err = Check(img, path, nil)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	err = Check(newImage(color.RGBA{255, 0, 0, 255}), path, nil)
+	if err == nil {
+		t.Error("difference not detected")
+	} else if _, errStat := os.Stat(strings.TrimSuffix(path, ".png") + "_diff.png"); errStat != nil {
+		t.Error(errStat)
+	}
+}
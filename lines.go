@@ -0,0 +1,255 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"math"
+)
+
+// Joins of line segments in Polyline.
+const (
+	JoinMiter = iota
+	JoinRound
+	JoinBevel
+)
+
+// Caps of line ends in Polyline.
+const (
+	CapButt = iota
+	CapSquare
+	CapRound
+)
+
+// miter is replaced by bevel, if it is longer than this times half thickness
+const miterLimit = 4
+
+// segments of a full circle in round joins and caps
+const roundSegments = 32
+
+// LinesLayer is a layer holding polylines.
+type LinesLayer struct {
+	entities     []*Polyline
+	entityNextId []int
+	count        int
+	Enabled      bool
+	vertices     []float32
+}
+
+// Polyline is an entity from a LinesLayer. Points are x and y pairs.
+// Colors are r, g, b, a per point; points without color have color R,
+// G, B, A. Join is one of JoinMiter, JoinRound or JoinBevel, Cap is one
+// of CapButt, CapSquare or CapRound. Closed polylines connect the last
+// point with the first one and have no caps.
+type Polyline struct {
+	id         int
+	Points     []float32
+	Colors     []float32
+	R, G, B, A float32
+	Thickness  float32
+	Join, Cap  int
+	Closed     bool
+	Enabled    bool
+}
+
+// NewEntity returns a new instance of Polyline (thickness 1, opaque
+// black).
+func (layer *LinesLayer) NewEntity() *Polyline {
+	var entity *Polyline
+	if len(layer.entityNextId) == 0 {
+		entity = new(Polyline)
+		entity.id = len(layer.entities)
+		layer.entities = append(layer.entities, entity)
+	} else {
+		idLast := len(layer.entityNextId) - 1
+		id := layer.entityNextId[idLast]
+		layer.entityNextId = layer.entityNextId[:idLast]
+		entity = layer.entities[id]
+		entity.Points, entity.Colors = entity.Points[:0], entity.Colors[:0]
+		entity.Join, entity.Cap, entity.Closed = JoinMiter, CapButt, false
+	}
+	entity.R, entity.G, entity.B, entity.A = 0, 0, 0, 1
+	entity.Thickness = 1
+	entity.Enabled = true
+	layer.count++
+	return entity
+}
+
+// Release releases the entity. This entity may be reused when calling NewEntity.
+func (layer *LinesLayer) Release(line *Polyline) *Polyline {
+	line.Enabled = false
+	layer.entityNextId = append(layer.entityNextId, line.id)
+	layer.count--
+	return nil
+}
+
+// SetRect sets points of polyline to the outline of a rectangle.
+func (line *Polyline) SetRect(x, y, width, height float32) {
+	line.Points = append(line.Points[:0], x, y, x+width, y, x+width, y+height, x, y+height)
+	line.Closed = true
+}
+
+// appendTriangles appends triangles of line to vertices (x, y, r, g, b,
+// a per vertex).
+func (line *Polyline) appendTriangles(vertices []float32) []float32 {
+	if line.Thickness > 0 {
+		var points [][2]float32
+		var colors [][4]float32
+		// consecutive equal points have no direction
+		for i := 0; i+1 < len(line.Points); i += 2 {
+			point := [2]float32{line.Points[i], line.Points[i+1]}
+			if len(points) == 0 || points[len(points)-1] != point {
+				points = append(points, point)
+				colors = append(colors, line.color(i/2))
+			}
+		}
+		if line.Closed && len(points) > 2 && points[0] == points[len(points)-1] {
+			points, colors = points[:len(points)-1], colors[:len(colors)-1]
+		}
+		if len(points) > 1 {
+			half := line.Thickness / 2
+			segments := len(points) - 1
+			if line.Closed && len(points) > 2 {
+				segments = len(points)
+			}
+			for i := 0; i < segments; i++ {
+				j := (i + 1) % len(points)
+				_, nrm := lineDirection(points[i], points[j])
+				vertices = appendQuad(vertices, points[i], points[j], nrm, half, colors[i], colors[j])
+			}
+			// joins
+			for i := 1; i < len(points)-1 || (segments == len(points) && i <= len(points)); i++ {
+				k := i % len(points)
+				prev := points[(k+len(points)-1)%len(points)]
+				next := points[(k+1)%len(points)]
+				vertices = appendJoin(vertices, prev, points[k], next, half, line.Join, colors[k])
+			}
+			// caps
+			if segments < len(points) {
+				last := len(points) - 1
+				vertices = appendCap(vertices, points[1], points[0], half, line.Cap, colors[0])
+				vertices = appendCap(vertices, points[last-1], points[last], half, line.Cap, colors[last])
+			}
+		}
+	}
+	return vertices
+}
+
+func (line *Polyline) color(index int) [4]float32 {
+	if index*4+3 < len(line.Colors) {
+		return [4]float32{line.Colors[index*4], line.Colors[index*4+1], line.Colors[index*4+2], line.Colors[index*4+3]}
+	}
+	return [4]float32{line.R, line.G, line.B, line.A}
+}
+
+// lineDirection returns normalized direction and normal from a to b.
+func lineDirection(a, b [2]float32) ([2]float32, [2]float32) {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	length := float32(math.Hypot(float64(dx), float64(dy)))
+	dx, dy = dx/length, dy/length
+	return [2]float32{dx, dy}, [2]float32{-dy, dx}
+}
+
+func appendQuad(vertices []float32, a, b, nrm [2]float32, half float32, colorA, colorB [4]float32) []float32 {
+	a0 := [2]float32{a[0] + nrm[0]*half, a[1] + nrm[1]*half}
+	a1 := [2]float32{a[0] - nrm[0]*half, a[1] - nrm[1]*half}
+	b0 := [2]float32{b[0] + nrm[0]*half, b[1] + nrm[1]*half}
+	b1 := [2]float32{b[0] - nrm[0]*half, b[1] - nrm[1]*half}
+	vertices = appendVertex(vertices, a0, colorA)
+	vertices = appendVertex(vertices, a1, colorA)
+	vertices = appendVertex(vertices, b0, colorB)
+	vertices = appendVertex(vertices, b0, colorB)
+	vertices = appendVertex(vertices, a1, colorA)
+	return appendVertex(vertices, b1, colorB)
+}
+
+// appendJoin fills the gap at the outer side of point between the segments.
+func appendJoin(vertices []float32, prev, point, next [2]float32, half float32, join int, color [4]float32) []float32 {
+	dirA, nrmA := lineDirection(prev, point)
+	dirB, nrmB := lineDirection(point, next)
+	cross := dirA[0]*dirB[1] - dirA[1]*dirB[0]
+	if cross > 1e-6 || cross < -1e-6 {
+		// outer side is opposite to the turn
+		side := float32(1)
+		if cross > 0 {
+			side = -1
+		}
+		outerA := [2]float32{nrmA[0] * side, nrmA[1] * side}
+		outerB := [2]float32{nrmB[0] * side, nrmB[1] * side}
+		edgeA := [2]float32{point[0] + outerA[0]*half, point[1] + outerA[1]*half}
+		edgeB := [2]float32{point[0] + outerB[0]*half, point[1] + outerB[1]*half}
+		switch join {
+		case JoinRound:
+			vertices = appendArc(vertices, point, outerA, outerB, half, color)
+		case JoinMiter:
+			miter := [2]float32{outerA[0] + outerB[0], outerA[1] + outerB[1]}
+			miterLen := float32(math.Hypot(float64(miter[0]), float64(miter[1])))
+			if miterLen > 1e-6 {
+				miter[0], miter[1] = miter[0]/miterLen, miter[1]/miterLen
+				length := half / (miter[0]*outerA[0] + miter[1]*outerA[1])
+				if length <= half*miterLimit {
+					tip := [2]float32{point[0] + miter[0]*length, point[1] + miter[1]*length}
+					vertices = appendTriangle(vertices, point, edgeA, tip, color)
+					return appendTriangle(vertices, point, tip, edgeB, color)
+				}
+			}
+			vertices = appendTriangle(vertices, point, edgeA, edgeB, color)
+		default:
+			vertices = appendTriangle(vertices, point, edgeA, edgeB, color)
+		}
+	}
+	return vertices
+}
+
+// appendCap adds the cap at end, coming from point before.
+func appendCap(vertices []float32, before, end [2]float32, half float32, capType int, color [4]float32) []float32 {
+	dir, nrm := lineDirection(before, end)
+	switch capType {
+	case CapSquare:
+		ext := [2]float32{end[0] + dir[0]*half, end[1] + dir[1]*half}
+		vertices = appendQuad(vertices, end, ext, nrm, half, color, color)
+	case CapRound:
+		negNrm := [2]float32{-nrm[0], -nrm[1]}
+		vertices = appendArc(vertices, end, nrm, dir, half, color)
+		vertices = appendArc(vertices, end, dir, negNrm, half, color)
+	}
+	return vertices
+}
+
+// appendArc adds a fan around center from direction a to b (the shorter way).
+func appendArc(vertices []float32, center, a, b [2]float32, radius float32, color [4]float32) []float32 {
+	angleA := math.Atan2(float64(a[1]), float64(a[0]))
+	angleB := math.Atan2(float64(b[1]), float64(b[0]))
+	delta := angleB - angleA
+	if delta > math.Pi {
+		delta -= 2 * math.Pi
+	} else if delta < -math.Pi {
+		delta += 2 * math.Pi
+	}
+	steps := int(math.Ceil(math.Abs(delta) / (2 * math.Pi / roundSegments)))
+	if steps < 1 {
+		steps = 1
+	}
+	prev := [2]float32{center[0] + a[0]*radius, center[1] + a[1]*radius}
+	for i := 1; i <= steps; i++ {
+		angle := angleA + delta*float64(i)/float64(steps)
+		curr := [2]float32{center[0] + float32(math.Cos(angle))*radius, center[1] + float32(math.Sin(angle))*radius}
+		vertices = appendTriangle(vertices, center, prev, curr, color)
+		prev = curr
+	}
+	return vertices
+}
+
+func appendTriangle(vertices []float32, a, b, c [2]float32, color [4]float32) []float32 {
+	vertices = appendVertex(vertices, a, color)
+	vertices = appendVertex(vertices, b, color)
+	return appendVertex(vertices, c, color)
+}
+
+func appendVertex(vertices []float32, point [2]float32, color [4]float32) []float32 {
+	return append(vertices, point[0], point[1], color[0], color[1], color[2], color[3])
+}
@@ -0,0 +1,49 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"testing"
+)
+
+func TestPolylineTriangles(t *testing.T) {
+	var layer LinesLayer
+	line := layer.NewEntity()
+	line.Thickness = 2
+	line.Points = []float32{0, 0, 10, 0, 10, 0}
+	line.Colors = []float32{1, 0, 0, 1}
+	vertices := line.appendTriangles(nil)
+	if len(vertices) != 6*6 {
+		t.Error("butt vertices", len(vertices)/6)
+	} else if vertices[0] != 0 || vertices[1] != 1 || vertices[2] != 1 || vertices[5] != 1 || vertices[12+2] != 0 {
+		t.Error("vertices", vertices[:18])
+	}
+	line.Points = append(line.Points[:0], 0, 0, 10, 0, 10, 10)
+	line.Join = JoinBevel
+	if vertices = line.appendTriangles(vertices[:0]); len(vertices) != 15*6 {
+		t.Error("bevel vertices", len(vertices)/6)
+	}
+	line.Join = JoinMiter
+	if vertices = line.appendTriangles(vertices[:0]); len(vertices) != 18*6 {
+		t.Error("miter vertices", len(vertices)/6)
+	} else if tip := vertices[12*6+12:]; tip[0] < 10.99 || tip[0] > 11.01 || tip[1] < -1.01 || tip[1] > -0.99 {
+		t.Error("miter tip", tip[:2])
+	}
+	line.Cap = CapSquare
+	if vertices = line.appendTriangles(vertices[:0]); len(vertices) != 30*6 {
+		t.Error("square cap vertices", len(vertices)/6)
+	}
+	line.SetRect(0, 0, 10, 5)
+	if vertices = line.appendTriangles(vertices[:0]); len(vertices) != 48*6 {
+		t.Error("rectangle vertices", len(vertices)/6)
+	}
+	layer.Release(line)
+	if line = layer.NewEntity(); line.Closed || len(line.Points) != 0 || line.Thickness != 1 {
+		t.Error("reused polyline", line)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"fmt"
+)
+
+// floats per vertex of meshes
+const meshVertexLen = 10
+
+// MeshLayer is a layer holding triangle meshes and polygons.
+type MeshLayer struct {
+	entities     []*Mesh
+	entityNextId []int
+	count        int
+	Enabled      bool
+	texMap       []int
+	vertices     []float32
+}
+
+// Mesh is an entity from a MeshLayer. Points are x and y pairs. Colors
+// are r, g, b, a per point; points without color have color R, G, B, A.
+// TexCoords are x and y pairs per point in pixels of texture TexRef (see
+// MeshLayer.UseTexture). Textures are multiplied with the color. Indices
+// are three per triangle; without indices every three points are a
+// triangle.
+type Mesh struct {
+	id         int
+	Points     []float32
+	Colors     []float32
+	TexCoords  []float32
+	Indices    []int
+	R, G, B, A float32
+	TexRef     int
+	Enabled    bool
+}
+
+// NewEntity returns a new instance of Mesh (opaque white, no texture).
+func (layer *MeshLayer) NewEntity() *Mesh {
+	var entity *Mesh
+	if len(layer.entityNextId) == 0 {
+		entity = new(Mesh)
+		entity.id = len(layer.entities)
+		layer.entities = append(layer.entities, entity)
+	} else {
+		idLast := len(layer.entityNextId) - 1
+		id := layer.entityNextId[idLast]
+		layer.entityNextId = layer.entityNextId[:idLast]
+		entity = layer.entities[id]
+		entity.Points, entity.Colors = entity.Points[:0], entity.Colors[:0]
+		entity.TexCoords, entity.Indices = entity.TexCoords[:0], entity.Indices[:0]
+	}
+	entity.R, entity.G, entity.B, entity.A = 1, 1, 1, 1
+	entity.TexRef = -1
+	entity.Enabled = true
+	layer.count++
+	return entity
+}
+
+// Release releases the entity. This entity may be reused when calling NewEntity.
+func (layer *MeshLayer) Release(mesh *Mesh) *Mesh {
+	mesh.Enabled = false
+	layer.entityNextId = append(layer.entityNextId, mesh.id)
+	layer.count--
+	return nil
+}
+
+// UseTexture associates a reference with a texture. Reference must be in range of [0, 15].
+func (layer *MeshLayer) UseTexture(ref, textureId int) {
+	if ref >= 0 && ref <= 15 {
+		if textureId >= 0 && textureId < MaxTextures {
+			if len(layer.texMap) == 0 {
+				layer.texMap = make([]int, 16, 16)
+			}
+			layer.texMap[ref] = textureId
+		} else {
+			panic(fmt.Sprintf("invalid texture id (%d)", textureId))
+		}
+	} else {
+		panic(fmt.Sprintf("invalid reference (%d) for texture (%d)", ref, textureId))
+	}
+}
+
+// SetPolygon sets points of mesh to a simple polygon (x and y pairs,
+// convex or concave) and triangulates it.
+func (mesh *Mesh) SetPolygon(points ...float32) {
+	mesh.Points = append(mesh.Points[:0], points...)
+	mesh.Indices = append(mesh.Indices[:0], Triangulate(points)...)
+}
+
+// Triangulate returns indices of triangles (three per triangle) covering
+// the simple polygon points (x and y pairs, clockwise or
+// counterclockwise). Polygons with less than three points return nil.
+func Triangulate(points []float32) []int {
+	var indices []int
+	count := len(points) / 2
+	if count >= 3 {
+		var area float32
+		remaining := make([]int, count)
+		for i := range remaining {
+			j := (i + 1) % count
+			remaining[i] = i
+			area += points[i*2]*points[j*2+1] - points[j*2]*points[i*2+1]
+		}
+		orientation := float32(1)
+		if area < 0 {
+			orientation = -1
+		}
+		indices = make([]int, 0, (count-2)*3)
+		for len(remaining) > 3 {
+			ear := earIndex(points, remaining, orientation)
+			prev := remaining[(ear+len(remaining)-1)%len(remaining)]
+			next := remaining[(ear+1)%len(remaining)]
+			indices = append(indices, prev, remaining[ear], next)
+			remaining = append(remaining[:ear], remaining[ear+1:]...)
+		}
+		indices = append(indices, remaining...)
+	}
+	return indices
+}
+
+// earIndex returns index in remaining of a convex vertex without other
+// vertices in its triangle. Degenerate polygons return 0.
+func earIndex(points []float32, remaining []int, orientation float32) int {
+	for i, curr := range remaining {
+		prev := remaining[(i+len(remaining)-1)%len(remaining)]
+		next := remaining[(i+1)%len(remaining)]
+		ax, ay := points[prev*2], points[prev*2+1]
+		bx, by := points[curr*2], points[curr*2+1]
+		cx, cy := points[next*2], points[next*2+1]
+		if triangleCross(ax, ay, bx, by, cx, cy)*orientation > 0 {
+			isEar := true
+			for _, other := range remaining {
+				if other != prev && other != curr && other != next {
+					px, py := points[other*2], points[other*2+1]
+					if (px != ax || py != ay) && (px != bx || py != by) && (px != cx || py != cy) {
+						d0 := triangleCross(ax, ay, bx, by, px, py) * orientation
+						d1 := triangleCross(bx, by, cx, cy, px, py) * orientation
+						d2 := triangleCross(cx, cy, ax, ay, px, py) * orientation
+						if d0 >= 0 && d1 >= 0 && d2 >= 0 {
+							isEar = false
+							break
+						}
+					}
+				}
+			}
+			if isEar {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// texMapsEqual returns true, if both maps associate the 16 references
+// with the same textures. Nil maps associate all with texture 0.
+func texMapsEqual(texMapA, texMapB []int) bool {
+	for i := 0; i < 16; i++ {
+		var textureIdA, textureIdB int
+		if i < len(texMapA) {
+			textureIdA = texMapA[i]
+		}
+		if i < len(texMapB) {
+			textureIdB = texMapB[i]
+		}
+		if textureIdA != textureIdB {
+			return false
+		}
+	}
+	return true
+}
+
+func triangleCross(ax, ay, bx, by, cx, cy float32) float32 {
+	return (bx-ax)*(cy-ay) - (by-ay)*(cx-ax)
+}
+
+// appendVertices appends triangles of mesh to vertices. Texture
+// coordinates are normalized with texture width and height.
+func (mesh *Mesh) appendVertices(vertices []float32, texWidth, texHeight float32) []float32 {
+	count := len(mesh.Points) / 2
+	if len(mesh.Indices) > 0 {
+		for i := 0; i+2 < len(mesh.Indices); i += 3 {
+			a, b, c := mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2]
+			if a >= 0 && a < count && b >= 0 && b < count && c >= 0 && c < count {
+				vertices = mesh.appendVertex(vertices, a, texWidth, texHeight)
+				vertices = mesh.appendVertex(vertices, b, texWidth, texHeight)
+				vertices = mesh.appendVertex(vertices, c, texWidth, texHeight)
+			}
+		}
+	} else {
+		for i := 0; i < count-count%3; i++ {
+			vertices = mesh.appendVertex(vertices, i, texWidth, texHeight)
+		}
+	}
+	return vertices
+}
+
+func (mesh *Mesh) appendVertex(vertices []float32, index int, texWidth, texHeight float32) []float32 {
+	vertices = append(vertices, mesh.Points[index*2], mesh.Points[index*2+1])
+	if index*4+3 < len(mesh.Colors) {
+		vertices = append(vertices, mesh.Colors[index*4:index*4+4]...)
+	} else {
+		vertices = append(vertices, mesh.R, mesh.G, mesh.B, mesh.A)
+	}
+	if mesh.TexRef >= 0 && mesh.TexRef <= 15 && index*2+1 < len(mesh.TexCoords) && texWidth > 0 && texHeight > 0 {
+		return append(vertices, float32(mesh.TexRef), mesh.TexCoords[index*2]/texWidth, mesh.TexCoords[index*2+1]/texHeight, 1)
+	}
+	return append(vertices, -1, 0, 0, 0)
+}
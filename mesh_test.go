@@ -0,0 +1,69 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"testing"
+)
+
+func TestTriangulate(t *testing.T) {
+	// concave (arrow), counterclockwise and clockwise
+	arrow := []float32{0, 0, 10, 5, 0, 10, 3, 5}
+	for _, points := range [][]float32{arrow, {3, 5, 0, 10, 10, 5, 0, 0}} {
+		indices := Triangulate(points)
+		if len(indices) != 6 {
+			t.Fatal("indices", indices)
+		}
+		var area float32
+		for i := 0; i < len(indices); i += 3 {
+			a, b, c := indices[i]*2, indices[i+1]*2, indices[i+2]*2
+			cross := triangleCross(points[a], points[a+1], points[b], points[b+1], points[c], points[c+1])
+			if cross < 0 {
+				cross = -cross
+			}
+			area += cross / 2
+		}
+		if area != 35 {
+			t.Error("area of", indices, "is", area)
+		}
+	}
+	if indices := Triangulate([]float32{0, 0, 1, 1}); indices != nil {
+		t.Error("line", indices)
+	}
+}
+
+func TestMeshVertices(t *testing.T) {
+	var layer MeshLayer
+	mesh := layer.NewEntity()
+	mesh.SetPolygon(0, 0, 10, 0, 10, 10, 0, 10)
+	mesh.TexRef, mesh.TexCoords = 0, []float32{0, 0, 32, 0, 32, 32, 0, 32}
+	vertices := mesh.appendVertices(nil, 32, 64)
+	if len(vertices) != 6*meshVertexLen {
+		t.Error("vertices", len(vertices)/meshVertexLen)
+	}
+	for i := 0; i < len(vertices); i += meshVertexLen {
+		if vertices[i+2] != 1 || vertices[i+6] != 0 || (vertices[i+7] != 0 && vertices[i+7] != 1) || (vertices[i+8] != 0 && vertices[i+8] != 0.5) {
+			t.Error("vertex", vertices[i:i+meshVertexLen])
+		}
+	}
+	mesh.Indices, mesh.TexRef = nil, -1
+	if vertices = mesh.appendVertices(vertices[:0], 0, 0); len(vertices) != 3*meshVertexLen || vertices[6] != -1 {
+		t.Error("triangle list", vertices)
+	}
+}
+
+func TestTexMapsEqual(t *testing.T) {
+	texMap := make([]int, 16)
+	if !texMapsEqual(nil, texMap) || !texMapsEqual(texMap, nil) {
+		t.Error("nil map not equal to zero map")
+	}
+	texMap[3] = 7
+	if texMapsEqual(nil, texMap) || texMapsEqual(texMap, nil) {
+		t.Error("nil map equal to", texMap)
+	}
+}
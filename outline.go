@@ -0,0 +1,351 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// transparent pixels around glyphs (for linear filtering)
+const glyphPadding = 1
+
+// OutlineFont is a TrueType or OpenType font. Glyphs missing in this
+// font are taken from Fallbacks. Fonts and their faces may be shared
+// between windows.
+type OutlineFont struct {
+	Fallbacks []*OutlineFont
+	font      *sfnt.Font
+	buffer    sfnt.Buffer
+	faces     map[tFaceKey]*FontFace
+	mutex     sync.Mutex
+}
+
+// FontFace is an OutlineFont at a size in pixels. Glyphs are rasterized
+// on demand into the glyph atlas (page 0, see TextLayer.UseTexture).
+type FontFace struct {
+	font   *OutlineFont
+	atlas  *GlyphAtlas
+	size   float32
+	ascent int
+	height int
+	glyphs map[rune]tFaceGlyph
+	spread int
+	mutex  sync.Mutex
+}
+
+// GlyphAtlas is a texture holding rasterized glyphs. Glyphs are white,
+// coverage is in alpha channel.
+type GlyphAtlas struct {
+	id          int
+	width       int
+	height      int
+	pixels      []byte
+	shelfX      int
+	shelfY      int
+	shelfHeight int
+	modified    bool
+	mutex       sync.Mutex
+}
+
+type tFaceKey struct {
+	size   float32
+	atlas  *GlyphAtlas
+	spread int
+}
+
+type tFaceGlyph struct {
+	Glyph
+	font  *OutlineFont
+	index sfnt.GlyphIndex
+	ok    bool
+}
+
+// LoadOutlineFont reads a font from TrueType or OpenType file.
+func LoadOutlineFont(path string) (*OutlineFont, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return ParseOutlineFont(data)
+	}
+	return nil, err
+}
+
+// ParseOutlineFont returns a font from TrueType or OpenType data.
+func ParseOutlineFont(data []byte) (*OutlineFont, error) {
+	font, err := sfnt.Parse(data)
+	if err == nil {
+		return &OutlineFont{font: font, faces: make(map[tFaceKey]*FontFace)}, nil
+	}
+	return nil, err
+}
+
+// Face returns font at size (in pixels) with glyphs rasterized into
+// atlas. Faces are cached, i.e. same size and atlas return same face.
+func (font *OutlineFont) Face(size float32, atlas *GlyphAtlas) *FontFace {
+	return font.face(size, atlas, 0)
+}
+
+// face returns cached face. Spread greater zero is for distance fields.
+func (font *OutlineFont) face(size float32, atlas *GlyphAtlas, spread int) *FontFace {
+	key := tFaceKey{size: size, atlas: atlas, spread: spread}
+	font.mutex.Lock()
+	defer font.mutex.Unlock()
+	face := font.faces[key]
+	if face == nil {
+		face = &FontFace{font: font, atlas: atlas, size: size, glyphs: make(map[rune]tFaceGlyph), spread: spread}
+		metrics, err := font.font.Metrics(&font.buffer, face.ppem(), xfont.HintingNone)
+		if err == nil {
+			face.ascent, face.height = metrics.Ascent.Ceil(), metrics.Height.Ceil()
+		} else {
+			face.ascent, face.height = int(size), int(size)
+		}
+		font.faces[key] = face
+	}
+	return face
+}
+
+// glyphIndex returns font (this or a fallback) containing r.
+func (font *OutlineFont) glyphIndex(r rune) (*OutlineFont, sfnt.GlyphIndex) {
+	return font.glyphIndexUnvisited(r, make(map[*OutlineFont]bool))
+}
+
+// glyphIndexUnvisited searches each font only once (fallbacks may
+// refer to each other).
+func (font *OutlineFont) glyphIndexUnvisited(r rune, visited map[*OutlineFont]bool) (*OutlineFont, sfnt.GlyphIndex) {
+	visited[font] = true
+	font.mutex.Lock()
+	index, err := font.font.GlyphIndex(&font.buffer, r)
+	font.mutex.Unlock()
+	if err == nil && index != 0 {
+		return font, index
+	}
+	for _, fallback := range font.Fallbacks {
+		if fallback != nil && !visited[fallback] {
+			if fontFound, index := fallback.glyphIndexUnvisited(r, visited); fontFound != nil {
+				return fontFound, index
+			}
+		}
+	}
+	return nil, 0
+}
+
+// Atlas returns the glyph atlas of face.
+func (face *FontFace) Atlas() *GlyphAtlas {
+	return face.atlas
+}
+
+// Measure returns width and height of text (UTF-8).
+func (face *FontFace) Measure(text string) (int, int) {
+	return measureText(face, text)
+}
+
+func (face *FontFace) ppem() fixed.Int26_6 {
+	return fixed.Int26_6(face.size*64 + 0.5)
+}
+
+func (face *FontFace) glyph(r rune) (Glyph, bool) {
+	face.mutex.Lock()
+	defer face.mutex.Unlock()
+	glyph, cached := face.glyphs[r]
+	if !cached {
+		glyph.font, glyph.index = face.font.glyphIndex(r)
+		if glyph.font == nil && r != utf8.RuneError {
+			// missing glyphs are replaced by U+FFFD, if available
+			glyph.font, glyph.index = face.font.glyphIndex(utf8.RuneError)
+		}
+		if glyph.font != nil {
+			glyph.Glyph, glyph.ok = face.rasterize(glyph.font, glyph.index)
+		}
+		face.glyphs[r] = glyph
+	}
+	return glyph.Glyph, glyph.ok
+}
+
+func (face *FontFace) kerning(first, second rune) int {
+	face.mutex.Lock()
+	glyphA, okA := face.glyphs[first]
+	glyphB, okB := face.glyphs[second]
+	face.mutex.Unlock()
+	if okA && okB && glyphA.ok && glyphB.ok && glyphA.font == glyphB.font {
+		glyphA.font.mutex.Lock()
+		kern, err := glyphA.font.font.Kern(&glyphA.font.buffer, glyphA.index, glyphB.index, face.ppem(), xfont.HintingNone)
+		glyphA.font.mutex.Unlock()
+		if err == nil {
+			return kern.Round()
+		}
+	}
+	return 0
+}
+
+func (face *FontFace) lineHeight() int {
+	return face.height
+}
+
+// rasterize draws glyph into atlas. Returns false, if atlas is full.
+func (face *FontFace) rasterize(font *OutlineFont, index sfnt.GlyphIndex) (Glyph, bool) {
+	var glyph Glyph
+	var alpha *image.Alpha
+	ppem := face.ppem()
+	// segments are stored in font's buffer
+	font.mutex.Lock()
+	advance, err := font.font.GlyphAdvance(&font.buffer, index, ppem, xfont.HintingNone)
+	if err == nil {
+		var segments sfnt.Segments
+		segments, err = font.font.LoadGlyph(&font.buffer, index, ppem, nil)
+		if err == nil {
+			glyph.XAdvance = advance.Round()
+			bounds := segments.Bounds()
+			padding := glyphPadding
+			if face.spread > 0 {
+				padding = face.spread
+			}
+			minX, minY := bounds.Min.X.Floor()-padding, bounds.Min.Y.Floor()-padding
+			width, height := bounds.Max.X.Ceil()+padding-minX, bounds.Max.Y.Ceil()+padding-minY
+			glyph.XOffset, glyph.YOffset = minX, minY+face.ascent
+			if len(segments) > 0 && width > 0 && height > 0 {
+				alpha = rasterizeSegments(segments, minX, minY, width, height)
+			}
+		}
+	}
+	font.mutex.Unlock()
+	if alpha != nil {
+		if face.spread > 0 {
+			alpha = distanceField(alpha, face.spread)
+		}
+		if face.atlas != nil {
+			glyph.X, glyph.Y, err = face.atlas.add(alpha)
+		} else {
+			err = errors.New("glyph atlas is nil")
+		}
+		if err == nil {
+			glyph.Width, glyph.Height = alpha.Rect.Dx(), alpha.Rect.Dy()
+		}
+	}
+	return glyph, err == nil
+}
+
+func rasterizeSegments(segments sfnt.Segments, minX, minY, width, height int) *image.Alpha {
+	raster := vector.NewRasterizer(width, height)
+	raster.DrawOp = draw.Src
+	dx, dy := float32(-minX), float32(-minY)
+	for i, segment := range segments {
+		args := segment.Args
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			if i > 0 {
+				raster.ClosePath()
+			}
+			raster.MoveTo(fixedToFloat(args[0].X)+dx, fixedToFloat(args[0].Y)+dy)
+		case sfnt.SegmentOpLineTo:
+			raster.LineTo(fixedToFloat(args[0].X)+dx, fixedToFloat(args[0].Y)+dy)
+		case sfnt.SegmentOpQuadTo:
+			raster.QuadTo(fixedToFloat(args[0].X)+dx, fixedToFloat(args[0].Y)+dy, fixedToFloat(args[1].X)+dx, fixedToFloat(args[1].Y)+dy)
+		case sfnt.SegmentOpCubeTo:
+			raster.CubeTo(fixedToFloat(args[0].X)+dx, fixedToFloat(args[0].Y)+dy, fixedToFloat(args[1].X)+dx, fixedToFloat(args[1].Y)+dy, fixedToFloat(args[2].X)+dx, fixedToFloat(args[2].Y)+dy)
+		}
+	}
+	raster.ClosePath()
+	alpha := image.NewAlpha(image.Rect(0, 0, width, height))
+	raster.Draw(alpha, alpha.Bounds(), image.Opaque, image.Point{})
+	return alpha
+}
+
+func fixedToFloat(value fixed.Int26_6) float32 {
+	return float32(value) / 64
+}
+
+// NewGlyphAtlas returns a new instance of GlyphAtlas. Load it with
+// Graphics.LoadTexture. When glyphs are added while drawing a TextLayer,
+// it is loaded again (and OnTextureLoaded is called again).
+func NewGlyphAtlas(textureId, width, height int) *GlyphAtlas {
+	atlas := &GlyphAtlas{id: textureId, width: width, height: height}
+	atlas.pixels = make([]byte, width*height*4)
+	for i := 0; i < len(atlas.pixels); i += 4 {
+		atlas.pixels[i], atlas.pixels[i+1], atlas.pixels[i+2] = 255, 255, 255
+	}
+	return atlas
+}
+
+// Id returns texture id.
+func (atlas *GlyphAtlas) Id() int {
+	return atlas.id
+}
+
+// RGBABytes returns a copy of the pixels.
+func (atlas *GlyphAtlas) RGBABytes() ([]byte, error) {
+	atlas.mutex.Lock()
+	bytes := make([]byte, len(atlas.pixels))
+	copy(bytes, atlas.pixels)
+	atlas.mutex.Unlock()
+	return bytes, nil
+}
+
+// Dimensions returns width and height of atlas.
+func (atlas *GlyphAtlas) Dimensions() (int, int) {
+	return atlas.width, atlas.height
+}
+
+// GenMipMap returns false.
+func (atlas *GlyphAtlas) GenMipMap() bool {
+	return false
+}
+
+// IsMipMap returns false.
+func (atlas *GlyphAtlas) IsMipMap() bool {
+	return false
+}
+
+// FilterLinear returns true.
+func (atlas *GlyphAtlas) FilterLinear() bool {
+	return true
+}
+
+// add copies alpha into a free area of the atlas (shelf packing) and
+// returns its position.
+func (atlas *GlyphAtlas) add(alpha *image.Alpha) (int, int, error) {
+	width, height := alpha.Rect.Dx(), alpha.Rect.Dy()
+	atlas.mutex.Lock()
+	defer atlas.mutex.Unlock()
+	if atlas.shelfX+width > atlas.width {
+		atlas.shelfX, atlas.shelfY, atlas.shelfHeight = 0, atlas.shelfY+atlas.shelfHeight+1, 0
+	}
+	if atlas.shelfX+width > atlas.width || atlas.shelfY+height > atlas.height {
+		return 0, 0, errors.New(fmt.Sprintf("glyph atlas (%d) is full", atlas.id))
+	}
+	x, y := atlas.shelfX, atlas.shelfY
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			atlas.pixels[((y+row)*atlas.width+x+col)*4+3] = alpha.Pix[row*alpha.Stride+col]
+		}
+	}
+	atlas.shelfX += width + 1
+	if height > atlas.shelfHeight {
+		atlas.shelfHeight = height
+	}
+	atlas.modified = true
+	return x, y, nil
+}
+
+// takeModified returns true once after glyphs have been added.
+func (atlas *GlyphAtlas) takeModified() bool {
+	atlas.mutex.Lock()
+	modified := atlas.modified
+	atlas.modified = false
+	atlas.mutex.Unlock()
+	return modified
+}
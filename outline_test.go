@@ -0,0 +1,96 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestFontFace(t *testing.T) {
+	font, err := ParseOutlineFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atlas := NewGlyphAtlas(3, 256, 256)
+	face := font.Face(20, atlas)
+	if face != font.Face(20, atlas) || face == font.Face(21, atlas) {
+		t.Error("faces are not cached per size")
+	}
+	glyph, ok := face.glyph('A')
+	if !ok || glyph.Width <= 2 || glyph.Height <= 2 || glyph.XAdvance <= 0 || glyph.YOffset <= 0 {
+		t.Fatal("glyph", glyph, ok)
+	}
+	if !atlas.takeModified() || atlas.takeModified() {
+		t.Error("atlas not modified once")
+	}
+	var opaque bool
+	pixels, _ := atlas.RGBABytes()
+	for y := glyph.Y; y < glyph.Y+glyph.Height; y++ {
+		for x := glyph.X; x < glyph.X+glyph.Width; x++ {
+			opaque = opaque || pixels[(y*256+x)*4+3] == 255
+		}
+	}
+	if !opaque {
+		t.Error("glyph not rasterized")
+	}
+	if space, ok := face.glyph(' '); !ok || space.Width != 0 || space.XAdvance <= 0 || atlas.takeModified() {
+		t.Error("space", space, ok)
+	}
+	width, height := face.Measure("AA\nA")
+	if width != glyph.XAdvance*2+face.kerning('A', 'A') || height != face.lineHeight()*2 {
+		t.Error("measure", width, height)
+	}
+}
+
+func TestFontFallback(t *testing.T) {
+	regular, _ := ParseOutlineFont(goregular.TTF)
+	mono, _ := ParseOutlineFont(gomono.TTF)
+	regular.Fallbacks = []*OutlineFont{mono}
+	if font, index := regular.glyphIndex('x'); font != regular || index == 0 {
+		t.Error("glyph not from font", font == mono)
+	}
+	// private use area is in none of the fonts
+	if font, _ := regular.glyphIndex('\ue000'); font != nil {
+		t.Error("glyph found")
+	}
+	// fonts falling back to each other
+	mono.Fallbacks = []*OutlineFont{regular}
+	if font, _ := mono.glyphIndex('\ue000'); font != nil {
+		t.Error("glyph found")
+	}
+	face := regular.Face(10, NewGlyphAtlas(0, 8, 8))
+	if glyph, ok := face.glyph('W'); ok {
+		t.Error("glyph in full atlas", glyph)
+	}
+}
+
+func TestFontShared(t *testing.T) {
+	font, _ := ParseOutlineFont(goregular.TTF)
+	atlas := NewGlyphAtlas(0, 512, 512)
+	done := make(chan bool)
+	// e.g. two windows drawing with the same font
+	for i := 0; i < 2; i++ {
+		go func() {
+			face := font.Face(16, atlas)
+			for r := 'a'; r <= 'z'; r++ {
+				face.glyph(r)
+				face.kerning('a', r)
+			}
+			done <- true
+		}()
+	}
+	<-done
+	<-done
+	face := font.Face(16, atlas)
+	if len(face.glyphs) != 26 {
+		t.Error("glyphs cached", len(face.glyphs))
+	}
+}
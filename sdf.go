@@ -0,0 +1,111 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"image"
+	"math"
+)
+
+// floats per vertex of distance field text
+const sdfVertexLen = 22
+
+// DistanceFace returns font at size (in pixels) with glyphs stored as
+// signed distance fields in atlas. Spread is the distance in pixels
+// covered around the outline (e.g. 4 to 8); it limits outline width,
+// shadow offset and softness of labels. Labels drawn with this face stay
+// sharp at any scale and rotation. Faces are cached like in Face.
+func (font *OutlineFont) DistanceFace(size float32, spread int, atlas *GlyphAtlas) *FontFace {
+	if spread < 1 {
+		spread = 1
+	}
+	return font.face(size, atlas, spread)
+}
+
+// distanceFace returns font of layer, if it is a distance field face.
+func (layer *TextLayer) distanceFace() *FontFace {
+	if face, ok := layer.Font.(*FontFace); ok && face.spread > 0 {
+		return face
+	}
+	return nil
+}
+
+// distanceField converts coverage to signed distance. Distance 0 (the
+// outline) is 127.5, spread inside is 255 and spread outside is 0.
+func distanceField(alpha *image.Alpha, spread int) *image.Alpha {
+	width, height := alpha.Rect.Dx(), alpha.Rect.Dy()
+	field := image.NewAlpha(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			coverage := alpha.Pix[y*alpha.Stride+x]
+			inside := coverage >= 128
+			var dist float64
+			if coverage > 0 && coverage < 255 {
+				// pixel on outline
+				dist = float64(coverage)/255 - 0.5
+			} else {
+				// nearest pixel on the other side
+				nearest := float64(spread) + 0.5
+				for dy := -spread; dy <= spread; dy++ {
+					for dx := -spread; dx <= spread; dx++ {
+						nx, ny := x+dx, y+dy
+						if nx >= 0 && nx < width && ny >= 0 && ny < height && (alpha.Pix[ny*alpha.Stride+nx] >= 128) != inside {
+							if d := math.Sqrt(float64(dx*dx + dy*dy)); d < nearest {
+								nearest = d
+							}
+						}
+					}
+				}
+				dist = nearest - 0.5
+				if !inside {
+					dist = -dist
+				}
+			}
+			value := 127.5 + dist*127.5/float64(spread)
+			field.Pix[y*field.Stride+x] = uint8(math.Max(0, math.Min(255, math.Round(value))))
+		}
+	}
+	return field
+}
+
+// appendDistanceVertices appends two triangles per glyph of label to
+// vertices (see g2d_gfx_draw_text_sdf). Texture coordinates are
+// normalized with texture width and height.
+func (label *Label) appendDistanceVertices(vertices []float32, face *FontFace, texWidth, texHeight float32) []float32 {
+	if texWidth > 0 && texHeight > 0 {
+		// effects in distance units (outline at 0.5)
+		unit := 1 / float32(2*face.spread)
+		outline := label.OutlineWidth * unit
+		softness := label.ShadowSoftness * unit
+		shadowU, shadowV := label.ShadowX/texWidth, label.ShadowY/texHeight
+		shadowA := label.ShadowA
+		if shadowA < 0 {
+			shadowA = 0
+		}
+		sin, cos := math.Sincos(float64(label.Rotation) * math.Pi / 180)
+		label.layout(face, func(glyph *Glyph, x, y float32) {
+			width, height := float32(glyph.Width)*label.Scale, float32(glyph.Height)*label.Scale
+			u0, v0 := float32(glyph.X)/texWidth, float32(glyph.Y)/texHeight
+			u1, v1 := float32(glyph.X+glyph.Width)/texWidth, float32(glyph.Y+glyph.Height)/texHeight
+			corners := [6][4]float32{{x, y, u0, v0}, {x + width, y, u1, v0}, {x, y + height, u0, v1}, {x, y + height, u0, v1}, {x + width, y, u1, v0}, {x + width, y + height, u1, v1}}
+			for _, corner := range corners {
+				cx, cy := corner[0], corner[1]
+				if label.Rotation != 0 {
+					x0, y0 := float64(cx-label.X), float64(cy-label.Y)
+					cx, cy = float32(x0*cos-y0*sin)+label.X, float32(x0*sin+y0*cos)+label.Y
+				}
+				vertices = append(vertices, cx, cy, label.R, label.G, label.B, label.A)
+				vertices = append(vertices, float32(glyph.Page), corner[2], corner[3], 0)
+				vertices = append(vertices, label.OutlineR, label.OutlineG, label.OutlineB, label.OutlineA)
+				vertices = append(vertices, label.ShadowR, label.ShadowG, label.ShadowB, shadowA)
+				vertices = append(vertices, outline, shadowU, shadowV, softness)
+			}
+		})
+	}
+	return vertices
+}
@@ -0,0 +1,55 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestDistanceField(t *testing.T) {
+	// square of 4x4 pixels in the middle of 12x12
+	alpha := image.NewAlpha(image.Rect(0, 0, 12, 12))
+	for y := 4; y < 8; y++ {
+		for x := 4; x < 8; x++ {
+			alpha.Pix[y*alpha.Stride+x] = 255
+		}
+	}
+	field := distanceField(alpha, 4)
+	center, edgeIn, edgeOut, far := field.Pix[5*12+5], field.Pix[5*12+4], field.Pix[5*12+3], field.Pix[0]
+	if center <= edgeIn || edgeIn <= 127 || edgeOut >= 128 || far != 0 {
+		t.Error("distances", center, edgeIn, edgeOut, far)
+	}
+}
+
+func TestDistanceVertices(t *testing.T) {
+	font, _ := ParseOutlineFont(goregular.TTF)
+	atlas := NewGlyphAtlas(0, 256, 256)
+	face := font.DistanceFace(32, 4, atlas)
+	if face == font.Face(32, atlas) || face != font.DistanceFace(32, 4, atlas) {
+		t.Error("distance faces are not cached separately")
+	}
+	layer := &TextLayer{Font: face}
+	if layer.distanceFace() != face {
+		t.Error("distance face not detected")
+	}
+	label := layer.NewEntity()
+	label.Text, label.OutlineWidth, label.ShadowX, label.ShadowA, label.Rotation = "I", 2, 4, 0.5, 90
+	vertices := label.appendDistanceVertices(nil, face, 256, 256)
+	if len(vertices) != 6*sdfVertexLen {
+		t.Fatal("vertices", len(vertices)/sdfVertexLen)
+	}
+	glyph, _ := face.glyph('I')
+	first := vertices[:sdfVertexLen]
+	// rotated by 90 degrees around origin: (x, y) -> (-y, x)
+	if first[0] > 0.01-float32(glyph.YOffset) || first[0] < -0.01-float32(glyph.YOffset) || first[17] != 0.5 || first[18] != 0.25 || first[19] != 4.0/256 {
+		t.Error("first vertex", first)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"math"
+)
+
+// Kinds of Shape.
+const (
+	ShapeEllipse = iota
+	ShapeRoundedRect
+	ShapeArc
+	ShapePie
+)
+
+// floats per vertex of shapes
+const shapeVertexLen = 20
+
+// ShapesLayer is a layer holding circles, ellipses, arcs, pie slices
+// and rounded rectangles. Edges are anti-aliased.
+type ShapesLayer struct {
+	entities     []*Shape
+	entityNextId []int
+	count        int
+	Enabled      bool
+	vertices     []float32
+}
+
+// Shape is an entity from a ShapesLayer. X, Y, Width and Height are
+// the bounding box of the shape (circles have equal Width and Height).
+// R, G, B, A is the fill color, StrokeR, StrokeG, StrokeB, StrokeA the
+// color of the outline, which lies inside the bounding box. Radius is
+// the corner radius of ShapeRoundedRect. StartAngle and EndAngle are
+// in degrees, clockwise from the positive x-axis, and limit ShapeArc
+// and ShapePie. ShapeArc is drawn with stroke only. Rotation is like in
+// Rectangle.
+type Shape struct {
+	id                        int
+	X, Y, Width, Height       float32
+	R, G, B, A                float32
+	StrokeR, StrokeG, StrokeB float32
+	StrokeA                   float32
+	StrokeWidth               float32
+	Radius                    float32
+	StartAngle, EndAngle      float32
+	RotX, RotY, RotAlpha      float32
+	Kind                      int
+	Enabled                   bool
+}
+
+// NewEntity returns a new instance of Shape (opaque white ellipse
+// without stroke).
+func (layer *ShapesLayer) NewEntity() *Shape {
+	var entity *Shape
+	if len(layer.entityNextId) == 0 {
+		entity = new(Shape)
+		entity.id = len(layer.entities)
+		layer.entities = append(layer.entities, entity)
+	} else {
+		idLast := len(layer.entityNextId) - 1
+		id := layer.entityNextId[idLast]
+		layer.entityNextId = layer.entityNextId[:idLast]
+		entity = layer.entities[id]
+		*entity = Shape{id: id}
+	}
+	entity.R, entity.G, entity.B, entity.A = 1, 1, 1, 1
+	entity.EndAngle = 360
+	entity.Enabled = true
+	layer.count++
+	return entity
+}
+
+// Release releases the entity. This entity may be reused when calling NewEntity.
+func (layer *ShapesLayer) Release(shape *Shape) *Shape {
+	shape.Enabled = false
+	layer.entityNextId = append(layer.entityNextId, shape.id)
+	layer.count--
+	return nil
+}
+
+// SetCircle sets shape to circle with center x, y and radius.
+func (shape *Shape) SetCircle(x, y, radius float32) {
+	shape.X, shape.Y, shape.Width, shape.Height = x-radius, y-radius, radius*2, radius*2
+}
+
+// appendVertices appends two triangles covering shape to vertices.
+func (shape *Shape) appendVertices(vertices []float32) []float32 {
+	hw, hh := shape.Width/2, shape.Height/2
+	cx, cy := shape.X+hw, shape.Y+hh
+	// margin for anti-aliasing and arc stroke
+	margin := 1 + shape.StrokeWidth/2
+	corners := [6][2]float32{{-1, -1}, {1, -1}, {-1, 1}, {-1, 1}, {1, -1}, {1, 1}}
+	sin, cos := math.Sincos(float64(shape.RotAlpha) * math.Pi / 180)
+	rx, ry := shape.X+shape.RotX, shape.Y+shape.RotY
+	for _, corner := range corners {
+		lx, ly := corner[0]*(hw+margin), corner[1]*(hh+margin)
+		x, y := cx+lx, cy+ly
+		if shape.RotAlpha != 0 {
+			x0, y0 := float64(x-rx), float64(y-ry)
+			x, y = float32(x0*cos-y0*sin)+rx, float32(x0*sin+y0*cos)+ry
+		}
+		vertices = append(vertices, x, y, lx, ly, hw, hh, float32(shape.Kind), shape.StrokeWidth)
+		vertices = append(vertices, shape.R, shape.G, shape.B, shape.A)
+		vertices = append(vertices, shape.StrokeR, shape.StrokeG, shape.StrokeB, shape.StrokeA)
+		vertices = append(vertices, shape.Radius, shape.StartAngle, shape.EndAngle, 0)
+	}
+	return vertices
+}
@@ -0,0 +1,36 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"testing"
+)
+
+func TestShapeVertices(t *testing.T) {
+	var layer ShapesLayer
+	shape := layer.NewEntity()
+	shape.SetCircle(10, 20, 5)
+	shape.Kind, shape.StrokeWidth = ShapePie, 2
+	vertices := shape.appendVertices(nil)
+	if len(vertices) != 6*shapeVertexLen {
+		t.Error("vertices", len(vertices)/shapeVertexLen)
+	} else {
+		first := vertices[:shapeVertexLen]
+		if first[0] != 3 || first[1] != 13 || first[2] != -7 || first[4] != 5 || first[6] != ShapePie || first[18] != 360 {
+			t.Error("first vertex", first)
+		}
+	}
+	shape.RotX, shape.RotY, shape.RotAlpha = 5, 5, 180
+	if last := shape.appendVertices(nil)[5*shapeVertexLen:]; last[0] < 2.99 || last[0] > 3.01 || last[1] < 12.99 || last[1] > 13.01 || last[2] != 7 {
+		t.Error("rotated vertex", last[:4])
+	}
+	layer.Release(shape)
+	if shape = layer.NewEntity(); shape.Kind != ShapeEllipse || shape.StrokeWidth != 0 || shape.A != 1 {
+		t.Error("reused shape", shape)
+	}
+}
@@ -0,0 +1,362 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Alignments of Label.
+const (
+	AlignLeft = iota
+	AlignCenter
+	AlignRight
+)
+
+// Font provides glyphs for TextLayer (see BitmapFont and FontFace).
+type Font interface {
+	glyph(r rune) (Glyph, bool)
+	kerning(first, second rune) int
+	lineHeight() int
+}
+
+// BitmapFont is a font from an AngelCode BMFont file. Pages are the file
+// names of the texture pages.
+type BitmapFont struct {
+	LineHeight int
+	Base       int
+	Pages      []string
+	Glyphs     map[rune]Glyph
+	kernings   map[[2]rune]int
+}
+
+// Glyph is a character in a texture page of a font. X, Y, Width and
+// Height are in pixels of the page.
+type Glyph struct {
+	X, Y, Width, Height int
+	XOffset, YOffset    int
+	XAdvance            int
+	Page                int
+}
+
+// TextLayer is a layer holding labels drawn with Font. Pages of the
+// font are associated with textures by UseTexture (page is reference).
+// Glyph atlases of FontFace are reloaded automatically, when glyphs
+// are added.
+type TextLayer struct {
+	entities     []*Label
+	entityNextId []int
+	count        int
+	Font         Font
+	Enabled      bool
+	texMap       []int
+	rects        []float32
+}
+
+// Label is an entity from a TextLayer. X and Y is the top left corner of
+// text with AlignLeft, the top center with AlignCenter and the top right
+// corner with AlignRight. Text may contain line breaks. Text is rotated
+// (in degrees) around X and Y. Outline and shadow are drawn only with
+// distance field fonts (see OutlineFont.DistanceFace); OutlineWidth,
+// ShadowX, ShadowY and ShadowSoftness are in pixels of the font size.
+// Shadow is drawn, if ShadowA is greater zero.
+type Label struct {
+	id                                 int
+	X, Y                               float32
+	Scale                              float32
+	Rotation                           float32
+	R, G, B, A                         float32
+	OutlineR, OutlineG, OutlineB       float32
+	OutlineA, OutlineWidth             float32
+	ShadowR, ShadowG, ShadowB, ShadowA float32
+	ShadowX, ShadowY, ShadowSoftness   float32
+	Text                               string
+	Align                              int
+	Enabled                            bool
+}
+
+// LoadBitmapFont reads a font from BMFont file (text or binary).
+func LoadBitmapFont(path string) (*BitmapFont, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return ParseBitmapFont(data)
+	}
+	return nil, err
+}
+
+// ParseBitmapFont returns a font from BMFont data (text or binary).
+func ParseBitmapFont(data []byte) (*BitmapFont, error) {
+	font := &BitmapFont{Glyphs: make(map[rune]Glyph), kernings: make(map[[2]rune]int)}
+	if bytes.HasPrefix(data, []byte("BMF")) {
+		return font, font.parseBinary(data)
+	}
+	return font, font.parseText(string(data))
+}
+
+func (font *BitmapFont) parseText(text string) error {
+	for lineNumber, line := range strings.Split(text, "\n") {
+		tag, values, err := bmfontLine(line)
+		if err != nil {
+			return errors.New(fmt.Sprintf("bmfont line %d: %s", lineNumber+1, err.Error()))
+		}
+		switch tag {
+		case "common":
+			font.LineHeight, font.Base = values["lineHeight"], values["base"]
+		case "page":
+			page := values["id"]
+			for len(font.Pages) <= page {
+				font.Pages = append(font.Pages, "")
+			}
+			font.Pages[page] = bmfontString(line, "file")
+		case "char":
+			font.Glyphs[rune(values["id"])] = Glyph{X: values["x"], Y: values["y"], Width: values["width"], Height: values["height"], XOffset: values["xoffset"], YOffset: values["yoffset"], XAdvance: values["xadvance"], Page: values["page"]}
+		case "kerning":
+			font.kernings[[2]rune{rune(values["first"]), rune(values["second"])}] = values["amount"]
+		}
+	}
+	return nil
+}
+
+// bmfontLine returns tag and integer values of line. Non-integer values
+// (e.g. face, file) are ignored.
+func bmfontLine(line string) (string, map[string]int, error) {
+	fields := strings.Fields(line)
+	values := make(map[string]int)
+	if len(fields) > 0 {
+		for _, field := range fields[1:] {
+			if key, value, ok := strings.Cut(field, "="); ok && len(value) > 0 && value[0] != '"' && !strings.Contains(value, ",") {
+				number, err := strconv.Atoi(value)
+				if err != nil {
+					return "", nil, err
+				}
+				values[key] = number
+			}
+		}
+		return fields[0], values, nil
+	}
+	return "", values, nil
+}
+
+// bmfontString returns quoted value of key in line.
+func bmfontString(line, key string) string {
+	if _, value, ok := strings.Cut(line, " "+key+"=\""); ok {
+		if end := strings.IndexByte(value, '"'); end >= 0 {
+			return value[:end]
+		}
+	}
+	return ""
+}
+
+func (font *BitmapFont) parseBinary(data []byte) error {
+	if len(data) < 4 || data[3] != 3 {
+		return errors.New("bmfont binary version not supported")
+	}
+	for offset := 4; offset < len(data); {
+		if offset+5 > len(data) {
+			return errors.New("bmfont block truncated")
+		}
+		blockType := data[offset]
+		size := int(binary.LittleEndian.Uint32(data[offset+1:]))
+		offset += 5
+		if offset+size > len(data) {
+			return errors.New(fmt.Sprintf("bmfont block (%d) truncated", blockType))
+		}
+		block := data[offset : offset+size]
+		offset += size
+		switch blockType {
+		case 2:
+			if len(block) >= 4 {
+				font.LineHeight = int(binary.LittleEndian.Uint16(block))
+				font.Base = int(binary.LittleEndian.Uint16(block[2:]))
+			}
+		case 3:
+			for _, page := range bytes.Split(block, []byte{0}) {
+				if len(page) > 0 {
+					font.Pages = append(font.Pages, string(page))
+				}
+			}
+		case 4:
+			for i := 0; i+20 <= len(block); i += 20 {
+				char := block[i : i+20]
+				font.Glyphs[rune(binary.LittleEndian.Uint32(char))] = Glyph{
+					X:        int(binary.LittleEndian.Uint16(char[4:])),
+					Y:        int(binary.LittleEndian.Uint16(char[6:])),
+					Width:    int(binary.LittleEndian.Uint16(char[8:])),
+					Height:   int(binary.LittleEndian.Uint16(char[10:])),
+					XOffset:  int(int16(binary.LittleEndian.Uint16(char[12:]))),
+					YOffset:  int(int16(binary.LittleEndian.Uint16(char[14:]))),
+					XAdvance: int(int16(binary.LittleEndian.Uint16(char[16:]))),
+					Page:     int(char[18])}
+			}
+		case 5:
+			for i := 0; i+10 <= len(block); i += 10 {
+				first := rune(binary.LittleEndian.Uint32(block[i:]))
+				second := rune(binary.LittleEndian.Uint32(block[i+4:]))
+				font.kernings[[2]rune{first, second}] = int(int16(binary.LittleEndian.Uint16(block[i+8:])))
+			}
+		}
+	}
+	return nil
+}
+
+// Kerning returns the horizontal adjustment between first and second.
+func (font *BitmapFont) Kerning(first, second rune) int {
+	return font.kerning(first, second)
+}
+
+// Measure returns width and height of text (unscaled).
+func (font *BitmapFont) Measure(text string) (int, int) {
+	return measureText(font, text)
+}
+
+func (font *BitmapFont) glyph(r rune) (Glyph, bool) {
+	glyph, ok := font.Glyphs[r]
+	return glyph, ok
+}
+
+func (font *BitmapFont) kerning(first, second rune) int {
+	return font.kernings[[2]rune{first, second}]
+}
+
+func (font *BitmapFont) lineHeight() int {
+	return font.LineHeight
+}
+
+func measureText(font Font, text string) (int, int) {
+	var width, lines int
+	for _, line := range strings.Split(text, "\n") {
+		if lineWidth := lineWidth(font, line); lineWidth > width {
+			width = lineWidth
+		}
+		lines++
+	}
+	return width, lines * font.lineHeight()
+}
+
+func lineWidth(font Font, line string) int {
+	var width int
+	prev := utf8.RuneError
+	for _, r := range line {
+		if glyph, ok := font.glyph(r); ok {
+			width += font.kerning(prev, r) + glyph.XAdvance
+		}
+		prev = r
+	}
+	return width
+}
+
+// NewEntity returns a new instance of Label (opaque white, not scaled).
+func (layer *TextLayer) NewEntity() *Label {
+	var entity *Label
+	if len(layer.entityNextId) == 0 {
+		entity = new(Label)
+		entity.id = len(layer.entities)
+		layer.entities = append(layer.entities, entity)
+	} else {
+		idLast := len(layer.entityNextId) - 1
+		id := layer.entityNextId[idLast]
+		layer.entityNextId = layer.entityNextId[:idLast]
+		entity = layer.entities[id]
+		*entity = Label{id: id}
+	}
+	entity.R, entity.G, entity.B, entity.A = 1, 1, 1, 1
+	entity.OutlineA = 1
+	entity.Scale = 1
+	entity.Enabled = true
+	layer.count++
+	return entity
+}
+
+// Release releases the entity. This entity may be reused when calling NewEntity.
+func (layer *TextLayer) Release(label *Label) *Label {
+	label.Enabled = false
+	layer.entityNextId = append(layer.entityNextId, label.id)
+	layer.count--
+	return nil
+}
+
+// UseTexture associates a page of font with a texture. Page must be in range of [0, 15].
+func (layer *TextLayer) UseTexture(page, textureId int) {
+	if page >= 0 && page <= 15 {
+		if textureId >= 0 && textureId < MaxTextures {
+			if len(layer.texMap) == 0 {
+				layer.texMap = make([]int, 16, 16)
+			}
+			layer.texMap[page] = textureId
+		} else {
+			panic(fmt.Sprintf("invalid texture id (%d)", textureId))
+		}
+	} else {
+		panic(fmt.Sprintf("invalid page (%d) for texture (%d)", page, textureId))
+	}
+}
+
+// Bounds returns position and size of label's text.
+func (layer *TextLayer) Bounds(label *Label) (float32, float32, float32, float32) {
+	if layer.Font != nil {
+		w, h := measureText(layer.Font, label.Text)
+		width, height := float32(w)*label.Scale, float32(h)*label.Scale
+		switch label.Align {
+		case AlignCenter:
+			return label.X - width/2, label.Y, width, height
+		case AlignRight:
+			return label.X - width, label.Y, width, height
+		}
+		return label.X, label.Y, width, height
+	}
+	return label.X, label.Y, 0, 0
+}
+
+// appendRects appends glyphs of label to rects (16 floats per rectangle,
+// see RectanglesLayer).
+func (label *Label) appendRects(rects []float32, font Font) []float32 {
+	label.layout(font, func(glyph *Glyph, x, y float32) {
+		texRef := float32(-1)
+		if glyph.Page >= 0 && glyph.Page <= 15 {
+			texRef = float32(glyph.Page)
+		}
+		rects = append(rects, x, y, float32(glyph.Width)*label.Scale, float32(glyph.Height)*label.Scale)
+		rects = append(rects, label.R, label.G, label.B, label.A, texRef)
+		rects = append(rects, float32(glyph.X), float32(glyph.Y), float32(glyph.Width), float32(glyph.Height))
+		rects = append(rects, label.X, label.Y, label.Rotation)
+	})
+	return rects
+}
+
+// layout calls draw for each visible glyph with its top left corner.
+func (label *Label) layout(font Font, draw func(glyph *Glyph, x, y float32)) {
+	y := label.Y
+	for _, line := range strings.Split(label.Text, "\n") {
+		x := label.X
+		switch label.Align {
+		case AlignCenter:
+			x -= float32(lineWidth(font, line)) * label.Scale / 2
+		case AlignRight:
+			x -= float32(lineWidth(font, line)) * label.Scale
+		}
+		prev := utf8.RuneError
+		for _, r := range line {
+			if glyph, ok := font.glyph(r); ok {
+				x += float32(font.kerning(prev, r)) * label.Scale
+				if glyph.Width > 0 && glyph.Height > 0 {
+					draw(&glyph, x+float32(glyph.XOffset)*label.Scale, y+float32(glyph.YOffset)*label.Scale)
+				}
+				x += float32(glyph.XAdvance) * label.Scale
+			}
+			prev = r
+		}
+		y += float32(font.lineHeight()) * label.Scale
+	}
+}
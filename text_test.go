@@ -0,0 +1,73 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package g2d
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+const testFnt = `info face="Test Font" size=16 bold=0 italic=0 charset="" unicode=1 stretchH=100 smooth=1 aa=1 padding=0,0,0,0 spacing=1,1
+common lineHeight=18 base=14 scaleW=256 scaleH=256 pages=1 packed=0
+page id=0 file="test_0.png"
+chars count=2
+char id=65   x=10    y=20    width=8     height=12    xoffset=1     yoffset=2     xadvance=9     page=0  chnl=15
+char id=86   x=20    y=20    width=8     height=12    xoffset=0     yoffset=2     xadvance=9     page=0  chnl=15
+kernings count=1
+kerning first=65  second=86  amount=-2
+`
+
+func TestParseBitmapFont(t *testing.T) {
+	font, err := ParseBitmapFont([]byte(testFnt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.LineHeight != 18 || font.Base != 14 || len(font.Pages) != 1 || font.Pages[0] != "test_0.png" {
+		t.Error("font", font.LineHeight, font.Base, font.Pages)
+	}
+	if glyph := font.Glyphs['A']; glyph.X != 10 || glyph.Width != 8 || glyph.XOffset != 1 || glyph.XAdvance != 9 {
+		t.Error("glyph", glyph)
+	}
+	if width, height := font.Measure("AV\nA"); width != 16 || height != 36 {
+		t.Error("measure", width, height)
+	}
+	// binary: common, pages, one char and one kerning pair
+	data := []byte{'B', 'M', 'F', 3, 2, 15, 0, 0, 0, 18, 0, 14, 0, 0, 1, 0, 1, 1, 0, 0, 0, 0, 0, 0}
+	data = append(data, 3, 11, 0, 0, 0)
+	data = append(data, "test_0.png\x00"...)
+	char := make([]byte, 20)
+	binary.LittleEndian.PutUint32(char, 'A')
+	binary.LittleEndian.PutUint16(char[8:], 8)
+	binary.LittleEndian.PutUint16(char[12:], 0xffff)
+	binary.LittleEndian.PutUint16(char[16:], 9)
+	data = append(append(data, 4, 20, 0, 0, 0), char...)
+	data = append(data, 5, 10, 0, 0, 0, 'A', 0, 0, 0, 'V', 0, 0, 0, 0xfe, 0xff)
+	if font, err = ParseBitmapFont(data); err != nil {
+		t.Fatal(err)
+	}
+	if font.LineHeight != 18 || font.Pages[0] != "test_0.png" || font.Glyphs['A'].XOffset != -1 || font.Glyphs['A'].Width != 8 || font.Kerning('A', 'V') != -2 {
+		t.Error("binary font", font)
+	}
+}
+
+func TestLabelRects(t *testing.T) {
+	var layer TextLayer
+	layer.Font, _ = ParseBitmapFont([]byte(testFnt))
+	label := layer.NewEntity()
+	label.X, label.Y, label.Text, label.Align, label.Scale = 100, 50, "AV", AlignRight, 2
+	rects := label.appendRects(nil, layer.Font)
+	if len(rects) != 32 {
+		t.Fatal("rects", len(rects))
+	}
+	if rects[0] != 70 || rects[1] != 54 || rects[2] != 16 || rects[8] != 0 || rects[9] != 10 || rects[16] != 82 {
+		t.Error("glyphs", rects)
+	}
+	if x, y, w, h := layer.Bounds(label); x != 68 || y != 50 || w != 32 || h != 36 {
+		t.Error("bounds", x, y, w, h)
+	}
+}
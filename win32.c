@@ -7,13 +7,29 @@
 
 #if defined(G2D_WIN32)
 
+/* declarations up to Windows 8 (pointer input); newer functions are loaded at runtime */
+#undef WINVER
+#undef _WIN32_WINNT
+#define WINVER       0x0602
+#define _WIN32_WINNT 0x0602
+
 #define WIN32_LEAN_AND_MEAN
 #include <windows.h>
+#include <xinput.h>
 #include <gl/GL.h>
 #include "g2d.h"
 #include "win32_errors.h"
 
 #define G2D_RESIZE_BORDER 4
+#define G2D_HIT_REGIONS_MAX 32
+#define G2D_OCCLUSION_TIMER 1
+#define G2D_OCCLUSION_INTERVAL 500
+
+/* from winuser.h (Windows 8.1 and 10) */
+#ifndef WM_DPICHANGED
+#define WM_DPICHANGED 0x02E0
+#endif
+#define G2D_DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2 ((HANDLE)-4)
 
 /* Go functions can not be passed to c directly.            */
 /* They can only be called from c.                          */
@@ -55,6 +71,7 @@
 #define GL_ELEMENT_ARRAY_BUFFER             0x8893
 #define GL_STATIC_DRAW                      0x88E4
 #define GL_DYNAMIC_DRAW                     0x88E8
+#define GL_STREAM_DRAW                      0x88E0
 #define GL_FRAGMENT_SHADER                  0x8B30
 #define GL_VERTEX_SHADER                    0x8B31
 #define GL_COMPILE_STATUS                   0x8B81
@@ -65,6 +82,9 @@
 #define GL_CLAMP_TO_EDGE                    0x812F
 #define GL_MAX_TEXTURE_IMAGE_UNITS          0x8872
 #define GL_MAX_COMBINED_TEXTURE_IMAGE_UNITS 0x8B4D
+#define GL_FRAMEBUFFER                      0x8D40
+#define GL_FRAMEBUFFER_COMPLETE             0x8CD5
+#define GL_COLOR_ATTACHMENT0                0x8CE0
 
 /* from wglext.h */
 typedef BOOL(WINAPI * PFNWGLCHOOSEPIXELFORMATARBPROC) (HDC hdc, const int *piAttribIList, const FLOAT *pfAttribFList, UINT nMaxFormats, int *piFormats, UINT *nNumFormats);
@@ -73,6 +93,24 @@ typedef const char *(WINAPI * PFNWGLGETEXTENSIONSSTRINGARBPROC) (HDC hdc);
 typedef BOOL(WINAPI * PFNWGLSWAPINTERVALEXTPROC) (int interval);
 typedef int (WINAPI * PFNWGLGETSWAPINTERVALEXTPROC) (void);
 
+/* from winuser.h */
+typedef BOOL (WINAPI * PFNGETPOINTERTYPEPROC) (UINT32 pointerId, POINTER_INPUT_TYPE *pointerType);
+typedef BOOL (WINAPI * PFNGETPOINTERTOUCHINFOPROC) (UINT32 pointerId, POINTER_TOUCH_INFO *touchInfo);
+typedef BOOL (WINAPI * PFNGETPOINTERPENINFOPROC) (UINT32 pointerId, POINTER_PEN_INFO *penInfo);
+typedef BOOL (WINAPI * PFNSETPROCESSDPIAWARENESSCONTEXTPROC) (HANDLE value);
+typedef UINT (WINAPI * PFNGETDPIFORWINDOWPROC) (HWND hwnd);
+typedef BOOL (WINAPI * PFNADJUSTWINDOWRECTEXFORDPIPROC) (LPRECT lpRect, DWORD dwStyle, BOOL bMenu, DWORD dwExStyle, UINT dpi);
+
+/* from shellscalingapi.h */
+typedef HRESULT (WINAPI * PFNGETDPIFORMONITORPROC) (HMONITOR hmonitor, int dpiType, UINT *dpiX, UINT *dpiY);
+typedef HRESULT (WINAPI * PFNSETPROCESSDPIAWARENESSPROC) (int value);
+
+/* from dwmapi.h */
+typedef HRESULT (WINAPI * PFNDWMGETWINDOWATTRIBUTEPROC) (HWND hwnd, DWORD dwAttribute, PVOID pvAttribute, DWORD cbAttribute);
+
+/* from xinput.h */
+typedef DWORD (WINAPI * PFNXINPUTGETSTATEPROC) (DWORD dwUserIndex, XINPUT_STATE *pState);
+
 // from glcorearb.h
 typedef char GLchar;
 typedef ptrdiff_t GLsizeiptr;
@@ -111,6 +149,10 @@ typedef void (APIENTRY *PFNGLUNIFORMMATRIX3FVPROC) (GLint location, GLsizei coun
 typedef void (APIENTRY *PFNGLUNIFORMMATRIX2X3FVPROC) (GLint location, GLsizei count, GLboolean transpose, const GLfloat *value);
 typedef void (APIENTRY *PFNGLGENERATEMIPMAPPROC) (GLenum target);
 typedef void (APIENTRY *PFNGLACTIVETEXTUREPROC) (GLenum texture);
+typedef void (APIENTRY *PFNGLGENFRAMEBUFFERSPROC) (GLsizei n, GLuint *framebuffers);
+typedef void (APIENTRY *PFNGLBINDFRAMEBUFFERPROC) (GLenum target, GLuint framebuffer);
+typedef void (APIENTRY *PFNGLFRAMEBUFFERTEXTURE2DPROC) (GLenum target, GLenum attachment, GLenum textarget, GLuint texture, GLint level);
+typedef GLenum (APIENTRY *PFNGLCHECKFRAMEBUFFERSTATUSPROC) (GLenum target);
 
 static PFNWGLCHOOSEPIXELFORMATARBPROC    wglChoosePixelFormatARB    = NULL;
 static PFNWGLCREATECONTEXTATTRIBSARBPROC wglCreateContextAttribsARB = NULL;
@@ -118,6 +160,15 @@ static PFNWGLGETEXTENSIONSSTRINGARBPROC  wglGetExtensionsStringARB  = NULL;
 static PFNWGLSWAPINTERVALEXTPROC         wglSwapIntervalEXT         = NULL;
 static PFNWGLGETSWAPINTERVALEXTPROC      wglGetSwapIntervalEXT      = NULL;
 
+static PFNGETPOINTERTYPEPROC             get_pointer_type           = NULL;
+static PFNGETPOINTERTOUCHINFOPROC        get_pointer_touch_info     = NULL;
+static PFNGETPOINTERPENINFOPROC          get_pointer_pen_info       = NULL;
+static PFNGETDPIFORWINDOWPROC            get_dpi_for_window         = NULL;
+static PFNADJUSTWINDOWRECTEXFORDPIPROC   adjust_window_rect_ex_for_dpi = NULL;
+static PFNXINPUTGETSTATEPROC             xinput_get_state           = NULL;
+static PFNGETDPIFORMONITORPROC           get_dpi_for_monitor        = NULL;
+static PFNDWMGETWINDOWATTRIBUTEPROC      dwm_get_window_attribute   = NULL;
+
 static PFNGLCREATESHADERPROC             glCreateShader             = NULL;
 static PFNGLSHADERSOURCEPROC             glShaderSource             = NULL;
 static PFNGLCOMPILESHADERPROC            glCompileShader            = NULL;
@@ -152,18 +203,32 @@ static PFNGLUNIFORMMATRIX3FVPROC         glUniformMatrix3fv         = NULL;
 static PFNGLUNIFORMMATRIX2X3FVPROC       glUniformMatrix2x3fv       = NULL;
 static PFNGLGENERATEMIPMAPPROC           glGenerateMipmap           = NULL;
 static PFNGLACTIVETEXTUREPROC            glActiveTexture            = NULL;
+static PFNGLGENFRAMEBUFFERSPROC          glGenFramebuffers          = NULL;
+static PFNGLDELETEFRAMEBUFFERSPROC       glDeleteFramebuffers       = NULL;
+static PFNGLBINDFRAMEBUFFERPROC          glBindFramebuffer          = NULL;
+static PFNGLFRAMEBUFFERTEXTURE2DPROC     glFramebufferTexture2D     = NULL;
+static PFNGLCHECKFRAMEBUFFERSTATUSPROC   glCheckFramebufferStatus   = NULL;
+
+/* program drawing vertex arrays without indices (triangles, shapes, meshes, text) */
+typedef struct { GLuint prog_ref, vao_ref, vbo_ref; GLint att_lc[6], unif_lc, tex_lc[16]; int texs; } arrays_t;
 
 typedef struct {
 	struct { HWND hndl; HDC dc; HGLRC rc; } wnd;
 	struct { int x, y, width, height; } client;
 	struct { int x, y, width, height; } client_bak;
-	struct { int x, y, double_clicked[5]; } mouse;
-	struct { int width_min, height_min, width_max, height_max, borderless, dragable, fullscreen, resizable, locked; DWORD style; } config;
-	struct { int dragging, minimized, maximized, resizing, focus, shown; } state;
+	struct { int x, y, inside, clicks[5], buttons, click_button, click_count, click_x, click_y; DWORD click_time, click_interval; } mouse;
+	struct { int width_min, height_min, width_max, height_max, borderless, dragable, fullscreen, resizable, locked, topmost, skip_taskbar, attention; float opacity; DWORD style; } config;
+	struct { HICON big, small; } icon;
+	struct { HWND hndl; int modal; } owner;
+	struct { int dragging, minimized, maximized, resizing, focus, shown, monitor, dpi, occluded; } state;
+	struct { int n, regions[G2D_HIT_REGIONS_MAX*5]; } hit;
+	struct { int exclusive, width, height, rate, bpp, changed; TCHAR device[CCHDEVICENAME]; } mode;
 	unsigned int key_repeated[255];
 	int cb_id;
-	struct { int r, g, b, w, h, i; GLfloat unif_data[16*3]; } gfx;
+	struct { int r, g, b, w, h, i; float s; GLfloat unif_data[16*3]; } gfx;
 	struct { GLuint prog_ref, vao_ref, vbo_ref, ebo_ref, buf_max_len; GLint att_lc[4], unif_lc[17]; GLfloat *buffer; } rects;
+	struct { GLuint *refs; int len; GLfloat clear[4]; } fbs;
+	arrays_t tris, shapes, mesh, sdf;
 } window_data_t;
 
 typedef void (gfx_draw_t)(void *data, float *rects, int total, long long *err1);
@@ -178,6 +243,7 @@ static const GLfloat default_projection_mat[16] = { 2.0f / 1.0f, 0.0f, 0.0f, 0.0
 static HINSTANCE instance = NULL;
 static BOOL initialized   = FALSE;
 static int windows_count  = 0;
+static int modes_changed  = 0;
 static DWORD thread_id    = 0;
 static BOOL stop          = FALSE;
 
@@ -188,7 +254,7 @@ in vec4 in1; \
 in vec4 in2; \
 in vec4 in3; \
 out vec4 fragementColor; \
-out vec3 texCoord; \
+out vec4 texCoord; \
 uniform float[48] unif; \
 void main() { \
   int tex = int(in2[0]); \
@@ -208,14 +274,14 @@ void main() { \
     int offset = 16 + tex*2; \
     float texWidth = unif[offset + 0]; \
     float texHeight = unif[offset + 1]; \
-    texCoord = vec3(in2[0], in3[0]/texWidth, in3[1]/texHeight); \
+    texCoord = vec4(in2[0], in3[0]/texWidth, in3[1]/texHeight, in2[2]); \
   } else { \
-    texCoord = vec3(-1.0, 0.0, 0.0); \
+    texCoord = vec4(-1.0, 0.0, 0.0, 0.0); \
   } \
 }";
 static LPCSTR const fs_rect_str = "#version 130\n\
 in vec4 fragementColor; \
-in vec3 texCoord; \
+in vec4 texCoord; \
 out vec4 color; \
 uniform sampler2D tex00; uniform sampler2D tex01; uniform sampler2D tex02; uniform sampler2D tex03; \
 uniform sampler2D tex04; uniform sampler2D tex05; uniform sampler2D tex06; uniform sampler2D tex07; \
@@ -242,11 +308,187 @@ void main() { \
       case 14: color = texture(tex14, vec2(texCoord[1], texCoord[2])); break; \
       case 15: color = texture(tex15, vec2(texCoord[1], texCoord[2])); break; \
     } \
+    if (texCoord[3] > 0.5) color = color * fragementColor; \
   } else { \
     color = fragementColor; \
   } \
 }";
 
+/* triangles with color per vertex (lines, meshes) */
+static LPCSTR const vs_tris_str = "#version 130\n\
+in vec2 in0; \
+in vec4 in1; \
+out vec4 fragementColor; \
+uniform float[16] unif; \
+void main() { \
+  mat4 projection = mat4(unif[0], unif[1], unif[2], unif[3], unif[4], unif[5], unif[6], unif[7], unif[8], unif[9], unif[10], unif[11], unif[12], unif[13], unif[14], unif[15]); \
+  gl_Position = projection * vec4(in0[0], in0[1], 1.0, 1.0); \
+  fragementColor = in1; \
+}";
+static LPCSTR const fs_tris_str = "#version 130\n\
+in vec4 fragementColor; \
+out vec4 color; \
+void main() { \
+  color = fragementColor; \
+}";
+
+/* textured triangles (meshes), fragment shader is fs_rect_str */
+static LPCSTR const vs_mesh_str = "#version 130\n\
+in vec2 in0; \
+in vec4 in1; \
+in vec4 in2; \
+out vec4 fragementColor; \
+out vec4 texCoord; \
+uniform float[16] unif; \
+void main() { \
+  mat4 projection = mat4(unif[0], unif[1], unif[2], unif[3], unif[4], unif[5], unif[6], unif[7], unif[8], unif[9], unif[10], unif[11], unif[12], unif[13], unif[14], unif[15]); \
+  gl_Position = projection * vec4(in0[0], in0[1], 1.0, 1.0); \
+  fragementColor = in1; \
+  texCoord = in2; \
+}";
+
+/* glyphs with signed distance in alpha channel, outline and shadow */
+static LPCSTR const vs_sdf_str = "#version 130\n\
+in vec2 in0; \
+in vec4 in1; \
+in vec4 in2; \
+in vec4 in3; \
+in vec4 in4; \
+in vec4 in5; \
+out vec4 fragementColor; \
+out vec4 texCoord; \
+out vec4 outlineColor; \
+out vec4 shadowColor; \
+out vec4 params; \
+uniform float[16] unif; \
+void main() { \
+  mat4 projection = mat4(unif[0], unif[1], unif[2], unif[3], unif[4], unif[5], unif[6], unif[7], unif[8], unif[9], unif[10], unif[11], unif[12], unif[13], unif[14], unif[15]); \
+  gl_Position = projection * vec4(in0[0], in0[1], 1.0, 1.0); \
+  fragementColor = in1; \
+  texCoord = in2; \
+  outlineColor = in3; \
+  shadowColor = in4; \
+  params = in5; \
+}";
+static LPCSTR const fs_sdf_str = "#version 130\n\
+in vec4 fragementColor; \
+in vec4 texCoord; \
+in vec4 outlineColor; \
+in vec4 shadowColor; \
+in vec4 params; \
+out vec4 color; \
+uniform sampler2D tex00; uniform sampler2D tex01; uniform sampler2D tex02; uniform sampler2D tex03; \
+uniform sampler2D tex04; uniform sampler2D tex05; uniform sampler2D tex06; uniform sampler2D tex07; \
+uniform sampler2D tex08; uniform sampler2D tex09; uniform sampler2D tex10; uniform sampler2D tex11; \
+uniform sampler2D tex12; uniform sampler2D tex13; uniform sampler2D tex14; uniform sampler2D tex15; \
+float dist(int tex, vec2 uv) { \
+  switch (tex) { \
+    case 0: return texture(tex00, uv).a; case 1: return texture(tex01, uv).a; \
+    case 2: return texture(tex02, uv).a; case 3: return texture(tex03, uv).a; \
+    case 4: return texture(tex04, uv).a; case 5: return texture(tex05, uv).a; \
+    case 6: return texture(tex06, uv).a; case 7: return texture(tex07, uv).a; \
+    case 8: return texture(tex08, uv).a; case 9: return texture(tex09, uv).a; \
+    case 10: return texture(tex10, uv).a; case 11: return texture(tex11, uv).a; \
+    case 12: return texture(tex12, uv).a; case 13: return texture(tex13, uv).a; \
+    case 14: return texture(tex14, uv).a; case 15: return texture(tex15, uv).a; \
+  } \
+  return 0.0; \
+} \
+void main() { \
+  int tex = int(texCoord[0]); vec2 uv = vec2(texCoord[1], texCoord[2]); \
+  float d = dist(tex, uv); \
+  float aa = max(fwidth(d) * 0.75, 0.001); \
+  float fill = smoothstep(0.5 - aa, 0.5 + aa, d); \
+  float edge = 0.5 - params[0]; \
+  float outline = smoothstep(edge - aa, edge + aa, d); \
+  vec4 text = mix(outlineColor, fragementColor, params[0] > 0.0 ? fill : 1.0); \
+  text.a = text.a * (params[0] > 0.0 ? outline : fill); \
+  float ds = dist(tex, uv - vec2(params[1], params[2])); \
+  float soft = max(params[3], aa); \
+  vec4 shadow = vec4(shadowColor.rgb, shadowColor.a * smoothstep(edge - soft, edge + soft, ds)); \
+  float a = text.a + shadow.a * (1.0 - text.a); \
+  color = a > 0.0 ? vec4((text.rgb * text.a + shadow.rgb * shadow.a * (1.0 - text.a)) / a, a) : vec4(0.0); \
+}";
+
+/* shapes with signed distance (ellipse, rounded rectangle, arc, pie) */
+static LPCSTR const vs_shapes_str = "#version 130\n\
+in vec4 in0; \
+in vec4 in1; \
+in vec4 in2; \
+in vec4 in3; \
+in vec4 in4; \
+out vec2 localPos; \
+out vec4 shape; \
+out vec4 fillColor; \
+out vec4 strokeColor; \
+out vec4 params; \
+uniform float[16] unif; \
+void main() { \
+  mat4 projection = mat4(unif[0], unif[1], unif[2], unif[3], unif[4], unif[5], unif[6], unif[7], unif[8], unif[9], unif[10], unif[11], unif[12], unif[13], unif[14], unif[15]); \
+  gl_Position = projection * vec4(in0[0], in0[1], 1.0, 1.0); \
+  localPos = vec2(in0[2], in0[3]); \
+  shape = in1; \
+  fillColor = in2; \
+  strokeColor = in3; \
+  params = in4; \
+}";
+static LPCSTR const fs_shapes_str = "#version 130\n\
+in vec2 localPos; \
+in vec4 shape; \
+in vec4 fillColor; \
+in vec4 strokeColor; \
+in vec4 params; \
+out vec4 color; \
+float ellipseDist(vec2 p, vec2 r) { \
+  float k0 = length(p/r); \
+  float k1 = length(p/(r*r)); \
+  return k1 > 0.0 ? k0*(k0-1.0)/k1 : -min(r.x, r.y); \
+} \
+float rayDist(vec2 p, float a) { \
+  vec2 dir = vec2(cos(a), sin(a)); \
+  return length(p - dir*max(dot(p, dir), 0.0)); \
+} \
+vec2 ellipsePoint(vec2 r, float a) { \
+  vec2 dir = vec2(cos(a), sin(a)); \
+  return dir / length(dir/r); \
+} \
+void main() { \
+  vec2 p = localPos; vec2 r = max(vec2(shape[0], shape[1]), vec2(0.0001)); \
+  int kind = int(shape[2]); float sw = shape[3]; \
+  float start = radians(params[1]); float span = radians(params[2] - params[1]); \
+  float t = mod(atan(p.y, p.x) - start, 6.2831853); \
+  bool full = span >= 6.2831853 || span <= -6.2831853; \
+  if (span < 0.0 && !full) { span = -span; t = mod(start - atan(p.y, p.x), 6.2831853); } \
+  float d; \
+  if (kind == 1) { \
+    float rad = min(params[0], min(r.x, r.y)); \
+    vec2 q = abs(p) - r + rad; \
+    d = length(max(q, 0.0)) + min(max(q.x, q.y), 0.0) - rad; \
+  } else if (kind == 2) { \
+    float end = radians(params[2]); \
+    if (full || t <= span) { \
+      d = abs(ellipseDist(p, r)); \
+    } else { \
+      d = min(length(p - ellipsePoint(r, start)), length(p - ellipsePoint(r, end))); \
+    } \
+    d = d - sw*0.5; \
+  } else { \
+    d = ellipseDist(p, r); \
+    if (kind == 3 && !full) { \
+      float dw = min(rayDist(p, start), rayDist(p, radians(params[2]))); \
+      d = max(d, t <= span ? -dw : dw); \
+    } \
+  } \
+  float aa = max(fwidth(d), 0.0001); \
+  float outer = clamp(0.5 - d/aa, 0.0, 1.0); \
+  if (kind == 2) { \
+    color = vec4(strokeColor.rgb, strokeColor.a*outer); \
+  } else { \
+    float inner = sw > 0.0 ? clamp(0.5 - (d+sw)/aa, 0.0, 1.0) : 1.0; \
+    vec4 c = mix(strokeColor, fillColor, inner); \
+    color = vec4(c.rgb, c.a*outer); \
+  } \
+}";
 
 void g2d_free(void *const data) {
 	free(data);
@@ -254,6 +496,9 @@ void g2d_free(void *const data) {
 
 #include "win32_debug.h"
 #include "win32_keys.h"
+#include "win32_pointer.h"
+#include "win32_gamepad.h"
+#include "win32_monitor.h"
 #include "win32_init.h"
 #include "win32_main_loop.h"
 #include "win32_graphics.h"
@@ -277,6 +522,11 @@ void g2d_post_quit(long long *const err1, long long *const err2) {
 void g2d_clean_up() {
 	MSG msg;
 	while (PeekMessage(&msg, NULL, 0, 0, PM_REMOVE));
+	/* restore desktop resolution (e.g. after an error) */
+	if (modes_changed > 0) {
+		ChangeDisplaySettingsEx(NULL, NULL, NULL, 0, NULL);
+		modes_changed = 0;
+	}
 }
 
 /* #if defined(G2D_WIN32) */
@@ -25,6 +25,7 @@
 
 #define G2D_ERR_0000017 17
 #define G2D_ERR_0000018 18
+#define G2D_ERR_0000019 19
 
 #define G2D_ERR_1000001 1000001
 #define G2D_ERR_1000002 1000002
@@ -66,6 +67,9 @@
 #define G2D_ERR_1001020 1001020
 #define G2D_ERR_1001021 1001021
 #define G2D_ERR_1001022 1001022
+#define G2D_ERR_1001023 1001023
+#define G2D_ERR_1001024 1001024
+#define G2D_ERR_1001025 1001025
 
 #define G2D_ERR_1002001 1002001
 #define G2D_ERR_1002002 1002002
@@ -129,3 +133,6 @@
 #define G2D_ERR_1002058 1002058
 #define G2D_ERR_1002059 1002059
 #define G2D_ERR_1002060 1002060
+
+#define G2D_ERR_1002061 1002061
+#define G2D_ERR_1002062 1002062
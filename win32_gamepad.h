@@ -0,0 +1,54 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+/* not defined in xinput.h (set by XInputGetStateEx only) */
+#define G2D_XINPUT_GAMEPAD_GUIDE 0x0400
+
+/* XInputGetStateEx writes 4 bytes more than XINPUT_STATE */
+typedef struct {
+	XINPUT_STATE state;
+	DWORD reserved;
+} xinput_state_ex_t;
+
+static float xinput_axis(const SHORT value) {
+	const float axis = (float)value / 32767.0f;
+	return axis < -1.0f ? -1.0f : axis;
+}
+
+/* raw state is in order of SDL's XInput mapping */
+void g2d_gamepad_poll(const int index, int *const connected, int *const buttons, float *const axes, int *const hat) {
+	xinput_state_ex_t state_ex;
+	XINPUT_STATE *const state = &state_ex.state;
+	connected[0] = 0;
+	if (xinput_get_state && xinput_get_state((DWORD)index, state) == ERROR_SUCCESS) {
+		const WORD bs = state[0].Gamepad.wButtons;
+		connected[0] = 1;
+		buttons[0] = (bs & XINPUT_GAMEPAD_A) != 0;
+		buttons[1] = (bs & XINPUT_GAMEPAD_B) != 0;
+		buttons[2] = (bs & XINPUT_GAMEPAD_X) != 0;
+		buttons[3] = (bs & XINPUT_GAMEPAD_Y) != 0;
+		buttons[4] = (bs & XINPUT_GAMEPAD_LEFT_SHOULDER) != 0;
+		buttons[5] = (bs & XINPUT_GAMEPAD_RIGHT_SHOULDER) != 0;
+		buttons[6] = (bs & XINPUT_GAMEPAD_BACK) != 0;
+		buttons[7] = (bs & XINPUT_GAMEPAD_START) != 0;
+		buttons[8] = (bs & XINPUT_GAMEPAD_LEFT_THUMB) != 0;
+		buttons[9] = (bs & XINPUT_GAMEPAD_RIGHT_THUMB) != 0;
+		buttons[10] = (bs & G2D_XINPUT_GAMEPAD_GUIDE) != 0;
+		/* y-axis is positive downwards */
+		axes[0] = xinput_axis(state[0].Gamepad.sThumbLX);
+		axes[1] = -xinput_axis(state[0].Gamepad.sThumbLY);
+		axes[2] = (float)state[0].Gamepad.bLeftTrigger / 255.0f * 2.0f - 1.0f;
+		axes[3] = xinput_axis(state[0].Gamepad.sThumbRX);
+		axes[4] = -xinput_axis(state[0].Gamepad.sThumbRY);
+		axes[5] = (float)state[0].Gamepad.bRightTrigger / 255.0f * 2.0f - 1.0f;
+		hat[0] = 0;
+		if (bs & XINPUT_GAMEPAD_DPAD_UP) hat[0] |= 1;
+		if (bs & XINPUT_GAMEPAD_DPAD_RIGHT) hat[0] |= 2;
+		if (bs & XINPUT_GAMEPAD_DPAD_DOWN) hat[0] |= 4;
+		if (bs & XINPUT_GAMEPAD_DPAD_LEFT) hat[0] |= 8;
+	}
+}
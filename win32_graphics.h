@@ -268,6 +268,65 @@ void g2d_gfx_gen_tex(void *const data, const void *const tex_data, const int gen
 	}
 }
 
+void g2d_gfx_gen_fb(void *const data, const int texture, const int tex_unit, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	if (tex_unit >= wnd_data[0].fbs.len) {
+		GLuint *const refs = (GLuint*)realloc(wnd_data[0].fbs.refs, sizeof(GLuint) * (tex_unit + 1));
+		if (refs) {
+			ZeroMemory(&refs[wnd_data[0].fbs.len], sizeof(GLuint) * (tex_unit + 1 - wnd_data[0].fbs.len));
+			wnd_data[0].fbs.refs = refs;
+			wnd_data[0].fbs.len = tex_unit + 1;
+		} else {
+			err1[0] = G2D_ERR_0000019;
+		}
+	}
+	if (err1[0] == 0) {
+		GLuint *const fb = &wnd_data[0].fbs.refs[tex_unit];
+		if (fb[0] == 0)
+			glGenFramebuffers(1, fb);
+		glBindFramebuffer(GL_FRAMEBUFFER, fb[0]);
+		glFramebufferTexture2D(GL_FRAMEBUFFER, GL_COLOR_ATTACHMENT0, GL_TEXTURE_2D, (GLuint)texture, 0);
+		if (glCheckFramebufferStatus(GL_FRAMEBUFFER) != GL_FRAMEBUFFER_COMPLETE)
+			err1[0] = G2D_ERR_1002061;
+		glBindFramebuffer(GL_FRAMEBUFFER, 0);
+	}
+}
+
+/* attributes are vec4, except first attribute of size size0; texs enables samplers tex00 to tex15 */
+static void arrays_init(arrays_t *const arrays, LPCSTR const vs_str, LPCSTR const fs_str, const int size0, const int atts, const int texs, long long *const err1, char **const err_nfo) {
+	const GLuint vs_id = shader_create(GL_VERTEX_SHADER, vs_str, G2D_ERR_1002002, G2D_ERR_1002003, err1, err_nfo);
+	if (err1[0] == 0) {
+		const GLuint fs_id = shader_create(GL_FRAGMENT_SHADER, fs_str, G2D_ERR_1002004, G2D_ERR_1002005, err1, err_nfo);
+		if (err1[0] == 0) {
+			int i;
+			const GLsizei stride = (GLsizei)(sizeof(GLfloat) * (size0 + (atts-1) * 4));
+			LPCSTR const names[6] = { "in0", "in1", "in2", "in3", "in4", "in5" };
+			arrays[0].prog_ref = rects_create(vs_id, fs_id, err1, err_nfo);
+			for (i = 0; i < atts; i++)
+				arrays[0].att_lc[i] = att_location(arrays[0].prog_ref, names[i], G2D_ERR_1002023, err1);
+			arrays[0].unif_lc = unif_location(arrays[0].prog_ref, "unif", G2D_ERR_1002025, G2D_ERR_1002026, err1);
+			arrays[0].texs = texs;
+			for (i = 0; i < 16 && texs; i++) {
+				char name[6] = { 't', 'e', 'x', (char)('0' + i / 10), (char)('0' + i % 10), 0 };
+				arrays[0].tex_lc[i] = unif_location(arrays[0].prog_ref, name, G2D_ERR_1002025, G2D_ERR_1002026, err1);
+			}
+			if (err1[0] == 0) {
+				GLuint objs[2]; glGenVertexArrays(1, objs); glGenBuffers(1, &objs[1]);
+				arrays[0].vao_ref = objs[0]; arrays[0].vbo_ref = objs[1];
+				bind_vao(arrays[0].vao_ref, G2D_ERR_1002027, err1);
+				for (i = 0; i < atts; i++)
+					enable_attr(arrays[0].att_lc[i], G2D_ERR_1002028, G2D_ERR_1002029, err1);
+				bind_vbo(arrays[0].vbo_ref, G2D_ERR_1002032, G2D_ERR_1002033, err1);
+				vertex_att_pointer(arrays[0].att_lc[0], size0, stride, (void*)0, G2D_ERR_1002042, G2D_ERR_1002043, G2D_ERR_1002044, err1);
+				for (i = 1; i < atts; i++)
+					vertex_att_pointer(arrays[0].att_lc[i], 4, stride, (void*)(sizeof(GLfloat) * (size0 + (i-1) * 4)), G2D_ERR_1002042, G2D_ERR_1002043, G2D_ERR_1002044, err1);
+			}
+			glDeleteShader(fs_id);
+		}
+		glDeleteShader(vs_id);
+	}
+}
+
 void g2d_gfx_init(void *const data, long long *const err1, long long *const err2, char **const err_nfo) {
 	window_data_t *const wnd_data = (window_data_t*)data;
 	if (wglMakeCurrent(wnd_data[0].wnd.dc, wnd_data[0].wnd.rc)) {
@@ -339,6 +398,14 @@ void g2d_gfx_init(void *const data, long long *const err1, long long *const err2
 			}
 			glDeleteShader(vs_id);
 		}
+		if (err1[0] == 0)
+			arrays_init(&wnd_data[0].tris, vs_tris_str, fs_tris_str, 2, 2, 0, err1, err_nfo);
+		if (err1[0] == 0)
+			arrays_init(&wnd_data[0].shapes, vs_shapes_str, fs_shapes_str, 4, 5, 0, err1, err_nfo);
+		if (err1[0] == 0)
+			arrays_init(&wnd_data[0].mesh, vs_mesh_str, fs_rect_str, 2, 3, 1, err1, err_nfo);
+		if (err1[0] == 0)
+			arrays_init(&wnd_data[0].sdf, vs_sdf_str, fs_sdf_str, 2, 6, 1, err1, err_nfo);
 		if (err1[0] == 0) {
 			glEnable(GL_BLEND);
 			glBlendFunc(GL_SRC_ALPHA, GL_ONE_MINUS_SRC_ALPHA);
@@ -349,18 +416,26 @@ void g2d_gfx_init(void *const data, long long *const err1, long long *const err2
 }
 
 void g2d_gfx_release(void *const data, long long *const err1, long long *const err2) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	/* framebuffer objects (refs array is freed with window) */
+	if (wnd_data[0].fbs.refs) {
+		glBindFramebuffer(GL_FRAMEBUFFER, 0);
+		glDeleteFramebuffers((GLsizei)wnd_data[0].fbs.len, wnd_data[0].fbs.refs);
+		ZeroMemory(wnd_data[0].fbs.refs, sizeof(GLuint) * wnd_data[0].fbs.len);
+	}
 	if (!wglMakeCurrent(NULL, NULL))
 		err1[0] = G2D_ERR_1002051, err2[0] = (long long)GetLastError();
 }
 
-void g2d_gfx_draw(void *const data, const int w, const int h, const int i, const float r, const float g, const float b,
-	float **const buffs, const int *const bs, void **const procs, const int l, long long *const err1, long long *const err2) {
+void g2d_gfx_draw(void *const data, const int w, const int h, const float s, const int i, const float r, const float g, const float b,
+	float **const buffs, const int *const bs, void **const procs, const int l, const int *const cr, void *const cp, long long *const err1, long long *const err2) {
 	int k;
 	window_data_t *const wnd_data = (window_data_t*)data;
-	if (wnd_data[0].gfx.w != w || wnd_data[0].gfx.g != h) {
-		wnd_data[0].gfx.w = w; wnd_data[0].gfx.h = h;
-		wnd_data[0].gfx.unif_data[0] = 2.0f / (GLfloat)w;
-		wnd_data[0].gfx.unif_data[5] = -2.0f / (GLfloat)h;
+	if (wnd_data[0].gfx.w != w || wnd_data[0].gfx.h != h || wnd_data[0].gfx.s != s) {
+		wnd_data[0].gfx.w = w; wnd_data[0].gfx.h = h; wnd_data[0].gfx.s = s;
+		/* projection in logical units, viewport in pixels */
+		wnd_data[0].gfx.unif_data[0] = 2.0f * (GLfloat)s / (GLfloat)w;
+		wnd_data[0].gfx.unif_data[5] = -2.0f * (GLfloat)s / (GLfloat)h;
 		glViewport((WORD)0, (WORD)0, (WORD)w, (WORD)h);
 	}
 	if (wnd_data[0].gfx.r != r || wnd_data[0].gfx.g != g || wnd_data[0].gfx.b != b) {
@@ -372,17 +447,28 @@ void g2d_gfx_draw(void *const data, const int w, const int h, const int i, const
 		wglSwapIntervalEXT(i);
 	}
 	glClear(GL_COLOR_BUFFER_BIT);
-	for (k = 0; k < l && bs[k] > 0 && err1[0] == 0; k++) {
-	//for (k = 0; k < l && err1[0] == 0; k++) {
-		gfx_draw_t *const draw = (gfx_draw_t*) procs[k];
-		draw(data, buffs[k], bs[k], err1);
+	/* empty batches (e.g. disabled layers) are skipped */
+	for (k = 0; k < l && err1[0] == 0; k++) {
+		if (bs[k] > 0) {
+			gfx_draw_t *const draw = (gfx_draw_t*) procs[k];
+			draw(data, buffs[k], bs[k], err1);
+		}
+	}
+	/* read back before swap, back buffer is undefined afterwards */
+	if (err1[0] == 0 && cp) {
+		/* clear previous error, it's not from reading */
+		glGetError();
+		glReadPixels((GLint)cr[0], (GLint)cr[1], (GLsizei)cr[2], (GLsizei)cr[3], GL_RGBA, GL_UNSIGNED_BYTE, cp);
+		if (glGetError() != GL_NO_ERROR)
+			err1[0] = G2D_ERR_1002062;
 	}
 	if (err1[0] == 0)
 		if (!SwapBuffers(wnd_data[0].wnd.dc))
 			err1[0] = G2D_ERR_1002050, err2[0] = (long long)GetLastError();
 }
 
-void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int total, long long *const err1) {
+/* tint multiplies texture with color (sprites) */
+static void rects_draw(void *const data, float *const rects, const int total, const GLfloat tint, long long *const err1) {
 	int rects_i, drawn;
 	window_data_t *const wnd_data = (window_data_t*)data;
 	const int length = (int)wnd_data[0].rects.buf_max_len;
@@ -417,6 +503,7 @@ void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int tot
 			buffer[offs+7] = a;
 			buffer[offs+8] = tex;
 			buffer[offs+9] = alpha;
+			buffer[offs+10] = tint;
 			buffer[offs+12] = tex_x;
 			buffer[offs+13] = tex_y;
 
@@ -430,6 +517,7 @@ void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int tot
 			buffer[offs+23] = a;
 			buffer[offs+24] = tex;
 			buffer[offs+25] = alpha;
+			buffer[offs+26] = tint;
 			buffer[offs+28] = tex_x + tex_w;
 			buffer[offs+29] = tex_y;
 
@@ -443,6 +531,7 @@ void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int tot
 			buffer[offs+39] = a;
 			buffer[offs+40] = tex;
 			buffer[offs+41] = alpha;
+			buffer[offs+42] = tint;
 			buffer[offs+44] = tex_x;
 			buffer[offs+45] = tex_y + tex_h;
 
@@ -456,6 +545,7 @@ void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int tot
 			buffer[offs+55] = a;
 			buffer[offs+56] = tex;
 			buffer[offs+57] = alpha;
+			buffer[offs+58] = tint;
 			buffer[offs+60] = tex_x + tex_w;
 			buffer[offs+61] = tex_y + tex_h;
 		}
@@ -463,3 +553,95 @@ void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int tot
 		draw_elements(limit * 6, G2D_ERR_1002017, G2D_ERR_1002018, G2D_ERR_1002019, err1);
 	}
 }
+
+void g2d_gfx_draw_rectangles(void *const data, float *const rects, const int total, long long *const err1) {
+	rects_draw(data, rects, total, 0.0f, err1);
+}
+
+void g2d_gfx_draw_sprites(void *const data, float *const sprites, const int total, long long *const err1) {
+	rects_draw(data, sprites, total, 1.0f, err1);
+}
+
+/* stride is number of floats per vertex, tex_units are 16 texture units (if texs) */
+static void arrays_draw(window_data_t *const wnd_data, const arrays_t *const arrays, float *const vertices, const int total, const int stride, const float *const tex_units, long long *const err1) {
+	prog_use(arrays[0].prog_ref, G2D_ERR_1002012, G2D_ERR_1002013, err1);
+	bind_vao(arrays[0].vao_ref, G2D_ERR_1002014, err1);
+	if (err1[0] == 0) {
+		int i;
+		glUniform1fv(arrays[0].unif_lc, 16, wnd_data[0].gfx.unif_data);
+		for (i = 0; i < 16 && arrays[0].texs; i++) {
+			const int tex_unit = (int)tex_units[i];
+			if (tex_unit >= 0)
+				glUniform1i(arrays[0].tex_lc[i], (GLint)tex_unit);
+		}
+		bind_vbo(arrays[0].vbo_ref, G2D_ERR_1002015, G2D_ERR_1002016, err1);
+		buffer_data(GL_ARRAY_BUFFER, sizeof(GLfloat) * total * stride, vertices, GL_STREAM_DRAW, G2D_ERR_1002020, G2D_ERR_1002021, G2D_ERR_1002022, G2D_ERR_1002022, err1);
+		if (err1[0] == 0) {
+			glDrawArrays(GL_TRIANGLES, 0, (GLsizei)total);
+			const GLenum err_enum = glGetError();
+			if (err_enum == GL_INVALID_ENUM) {
+				err1[0] = G2D_ERR_1002017;
+			} else if (err_enum == GL_INVALID_VALUE) {
+				err1[0] = G2D_ERR_1002018;
+			} else if (err_enum == GL_INVALID_OPERATION) {
+				err1[0] = G2D_ERR_1002019;
+			}
+		}
+	}
+}
+
+/* vertices are x, y, r, g, b, a */
+void g2d_gfx_draw_triangles(void *const data, float *const vertices, const int total, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	arrays_draw(wnd_data, &wnd_data[0].tris, vertices, total, 6, NULL, err1);
+}
+
+/* vertices are x, y, local x, local y, half width, half height, kind, stroke width,
+   fill rgba, stroke rgba, radius, start angle, end angle, 0 */
+void g2d_gfx_draw_shapes(void *const data, float *const vertices, const int total, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	arrays_draw(wnd_data, &wnd_data[0].shapes, vertices, total, 20, NULL, err1);
+}
+
+/* mesh is 16 texture units followed by vertices x, y, r, g, b, a, tex, u, v, tint */
+void g2d_gfx_draw_mesh(void *const data, float *const mesh, const int total, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	arrays_draw(wnd_data, &wnd_data[0].mesh, mesh + 16, total, 10, mesh, err1);
+}
+
+/* text is 16 texture units followed by vertices x, y, r, g, b, a, tex, u, v, 0,
+   outline rgba, shadow rgba, outline width, shadow u, shadow v, shadow softness */
+void g2d_gfx_draw_text_sdf(void *const data, float *const text, const int total, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	arrays_draw(wnd_data, &wnd_data[0].sdf, text + 16, total, 22, text, err1);
+}
+
+void g2d_gfx_draw_fb_begin(void *const data, float *const fb, const int total, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	const int tex_unit = (int)fb[0];
+	const GLfloat w = fb[1], h = fb[2];
+	glGetFloatv(GL_COLOR_CLEAR_VALUE, wnd_data[0].fbs.clear);
+	if (tex_unit >= 0 && tex_unit < wnd_data[0].fbs.len && wnd_data[0].fbs.refs[tex_unit]) {
+		glBindFramebuffer(GL_FRAMEBUFFER, wnd_data[0].fbs.refs[tex_unit]);
+		glViewport(0, 0, (GLsizei)w, (GLsizei)h);
+		/* y axis is flipped, so that first row is at TexY 0 (like in loaded textures) */
+		wnd_data[0].gfx.unif_data[0] = 2.0f / w;
+		wnd_data[0].gfx.unif_data[5] = 2.0f / h;
+		wnd_data[0].gfx.unif_data[13] = -1.0f;
+		glClearColor((GLfloat)fb[3], (GLfloat)fb[4], (GLfloat)fb[5], (GLfloat)fb[6]);
+		glClear(GL_COLOR_BUFFER_BIT);
+	} else {
+		/* framebuffer not created, yet; nothing is drawn */
+		glViewport(0, 0, 0, 0);
+	}
+}
+
+void g2d_gfx_draw_fb_end(void *const data, float *const fb, const int total, long long *const err1) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	glBindFramebuffer(GL_FRAMEBUFFER, 0);
+	glViewport((WORD)0, (WORD)0, (WORD)wnd_data[0].gfx.w, (WORD)wnd_data[0].gfx.h);
+	wnd_data[0].gfx.unif_data[0] = 2.0f * (GLfloat)wnd_data[0].gfx.s / (GLfloat)wnd_data[0].gfx.w;
+	wnd_data[0].gfx.unif_data[5] = -2.0f * (GLfloat)wnd_data[0].gfx.s / (GLfloat)wnd_data[0].gfx.h;
+	wnd_data[0].gfx.unif_data[13] = 1.0f;
+	glClearColor(wnd_data[0].fbs.clear[0], wnd_data[0].fbs.clear[1], wnd_data[0].fbs.clear[2], wnd_data[0].fbs.clear[3]);
+}
@@ -14,6 +14,41 @@ void g2d_init(int *const numbers, long long *const err1, long long *const err2,
 		/* module */
 		instance = GetModuleHandle(NULL);
 		if (instance) {
+			/* optional user32 functions (not available on every Windows version) */
+			HMODULE const user32 = GetModuleHandle(TEXT("user32.dll"));
+			if (user32) {
+				get_pointer_type = (PFNGETPOINTERTYPEPROC)GetProcAddress(user32, "GetPointerType");
+				get_pointer_touch_info = (PFNGETPOINTERTOUCHINFOPROC)GetProcAddress(user32, "GetPointerTouchInfo");
+				get_pointer_pen_info = (PFNGETPOINTERPENINFOPROC)GetProcAddress(user32, "GetPointerPenInfo");
+				get_dpi_for_window = (PFNGETDPIFORWINDOWPROC)GetProcAddress(user32, "GetDpiForWindow");
+				adjust_window_rect_ex_for_dpi = (PFNADJUSTWINDOWRECTEXFORDPIPROC)GetProcAddress(user32, "AdjustWindowRectExForDpi");
+			}
+			/* optional shcore function (Windows 8.1) */
+			HMODULE const shcore = LoadLibrary(TEXT("shcore.dll"));
+			if (shcore)
+				get_dpi_for_monitor = (PFNGETDPIFORMONITORPROC)GetProcAddress(shcore, "GetDpiForMonitor");
+			/* DPI awareness (per monitor on Windows 10 and 8.1, system wide before) */
+			{
+				PFNSETPROCESSDPIAWARENESSCONTEXTPROC const set_context = user32 ? (PFNSETPROCESSDPIAWARENESSCONTEXTPROC)GetProcAddress(user32, "SetProcessDpiAwarenessContext") : NULL;
+				PFNSETPROCESSDPIAWARENESSPROC const set_awareness = shcore ? (PFNSETPROCESSDPIAWARENESSPROC)GetProcAddress(shcore, "SetProcessDpiAwareness") : NULL;
+				if (!set_context || !set_context(G2D_DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2))
+					if (!set_awareness || set_awareness(2 /* PROCESS_PER_MONITOR_DPI_AWARE */) != S_OK)
+						SetProcessDPIAware();
+			}
+			/* optional DWM function (occlusion of windows) */
+			HMODULE const dwmapi = LoadLibrary(TEXT("dwmapi.dll"));
+			if (dwmapi)
+				dwm_get_window_attribute = (PFNDWMGETWINDOWATTRIBUTEPROC)GetProcAddress(dwmapi, "DwmGetWindowAttribute");
+			/* optional XInput (gamepads) */
+			HMODULE xinput = LoadLibrary(TEXT("xinput1_4.dll"));
+			if (!xinput)
+				xinput = LoadLibrary(TEXT("xinput9_1_0.dll"));
+			if (xinput) {
+				/* undocumented XInputGetStateEx (ordinal 100) reports guide button */
+				xinput_get_state = (PFNXINPUTGETSTATEPROC)GetProcAddress(xinput, (LPCSTR)100);
+				if (!xinput_get_state)
+					xinput_get_state = (PFNXINPUTGETSTATEPROC)GetProcAddress(xinput, "XInputGetState");
+			}
 			/* dummy class */
 			WNDCLASSEX cls;
 			ZeroMemory(&cls, sizeof(WNDCLASSEX));
@@ -121,6 +156,11 @@ void g2d_init(int *const numbers, long long *const err1, long long *const err2,
 										LOAD_OGL(PFNGLUNIFORMMATRIX2X3FVPROC,       glUniformMatrix2x3fv)
 										LOAD_OGL(PFNGLGENERATEMIPMAPPROC,           glGenerateMipmap)
 										LOAD_OGL(PFNGLACTIVETEXTUREPROC,            glActiveTexture)
+										LOAD_OGL(PFNGLGENFRAMEBUFFERSPROC,          glGenFramebuffers)
+										LOAD_OGL(PFNGLDELETEFRAMEBUFFERSPROC,       glDeleteFramebuffers)
+										LOAD_OGL(PFNGLBINDFRAMEBUFFERPROC,          glBindFramebuffer)
+										LOAD_OGL(PFNGLFRAMEBUFFERTEXTURE2DPROC,     glFramebufferTexture2D)
+										LOAD_OGL(PFNGLCHECKFRAMEBUFFERSTATUSPROC,   glCheckFramebufferStatus)
 										/* destroy dummy */
 										if (wglGetCurrentContext() == dummy_rc && !wglMakeCurrent(NULL, NULL) && err1[0] == 0) {
 											err1[0] = G2D_ERR_1000009; err2[0] = (long long)GetLastError();
@@ -0,0 +1,178 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+#define G2D_MONITORS_MAX 16
+
+typedef struct { HMONITOR hndl[G2D_MONITORS_MAX]; int count; } monitors_t;
+
+static void monitor_metrics(HMONITOR const monitor, int *const x, int *const y, int *const w, int *const h) {
+	MONITORINFO mi = { sizeof(mi) }; GetMonitorInfo(monitor, &mi);
+	x[0] = mi.rcMonitor.left; y[0] = mi.rcMonitor.top;
+	w[0] = mi.rcMonitor.right - mi.rcMonitor.left; h[0] = mi.rcMonitor.bottom - mi.rcMonitor.top;
+}
+
+static BOOL CALLBACK monitors_enum_proc(HMONITOR const monitor, HDC const dc, LPRECT const rect, LPARAM const data) {
+	monitors_t *const monitors = (monitors_t*)data;
+	if (monitors[0].count < G2D_MONITORS_MAX) {
+		MONITORINFO mi = { sizeof(mi) };
+		/* primary monitor has index 0 */
+		if (monitors[0].count > 0 && GetMonitorInfo(monitor, &mi) && (mi.dwFlags & MONITORINFOF_PRIMARY)) {
+			monitors[0].hndl[monitors[0].count] = monitors[0].hndl[0];
+			monitors[0].hndl[0] = monitor;
+		} else {
+			monitors[0].hndl[monitors[0].count] = monitor;
+		}
+		monitors[0].count++;
+	}
+	return TRUE;
+}
+
+static void monitors_enum(monitors_t *const monitors) {
+	monitors[0].count = 0;
+	EnumDisplayMonitors(NULL, NULL, monitors_enum_proc, (LPARAM)monitors);
+}
+
+static int monitor_index(HMONITOR const monitor) {
+	int i; monitors_t monitors; monitors_enum(&monitors);
+	for (i = 0; i < monitors.count; i++)
+		if (monitors.hndl[i] == monitor)
+			return i;
+	return 0;
+}
+
+static HMONITOR monitor_by_index(const int index) {
+	monitors_t monitors; monitors_enum(&monitors);
+	if (index >= 0 && index < monitors.count)
+		return monitors.hndl[index];
+	return MonitorFromWindow(NULL, MONITOR_DEFAULTTOPRIMARY);
+}
+
+static float monitor_scale(HMONITOR const monitor) {
+	UINT dpi_x, dpi_y;
+	if (get_dpi_for_monitor && get_dpi_for_monitor(monitor, 0 /* MDT_EFFECTIVE_DPI */, &dpi_x, &dpi_y) == S_OK) {
+		return (float)dpi_x / 96.0f;
+	} else {
+		HDC const dc = GetDC(NULL);
+		if (dc) {
+			const int dpi = GetDeviceCaps(dc, LOGPIXELSX);
+			ReleaseDC(NULL, dc);
+			return (float)dpi / 96.0f;
+		}
+	}
+	return 1.0f;
+}
+
+static int window_dpi(window_data_t *const wnd_data) {
+	if (get_dpi_for_window)
+		return (int)get_dpi_for_window(wnd_data[0].wnd.hndl);
+	return (int)(monitor_scale(MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST)) * 96.0f + 0.5f);
+}
+
+static void monitor_update(window_data_t *const wnd_data) {
+	wnd_data[0].state.monitor = monitor_index(MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST));
+}
+
+static void tstr_to_utf8(LPCTSTR const str, char *const buffer, const int length) {
+	#ifdef UNICODE
+	const int n = WideCharToMultiByte(CP_UTF8, 0, str, -1, buffer, length, NULL, NULL);
+	buffer[n > 0 ? n - 1 : 0] = 0;
+	#else
+	strncpy(buffer, str, length - 1);
+	buffer[length - 1] = 0;
+	#endif
+}
+
+static LONG video_mode_change(window_data_t *const wnd_data, HMONITOR const monitor) {
+	LONG result = DISP_CHANGE_SUCCESSFUL;
+	/* zero size keeps desktop resolution */
+	if (wnd_data[0].mode.width > 0 && wnd_data[0].mode.height > 0) {
+		MONITORINFOEX mi; ZeroMemory(&mi, sizeof(mi)); mi.cbSize = sizeof(mi);
+		if (GetMonitorInfo(monitor, (LPMONITORINFO)&mi)) {
+			DEVMODE dm; ZeroMemory(&dm, sizeof(dm)); dm.dmSize = sizeof(dm);
+			dm.dmPelsWidth = (DWORD)wnd_data[0].mode.width;
+			dm.dmPelsHeight = (DWORD)wnd_data[0].mode.height;
+			dm.dmFields = DM_PELSWIDTH | DM_PELSHEIGHT;
+			if (wnd_data[0].mode.rate > 0) {
+				dm.dmDisplayFrequency = (DWORD)wnd_data[0].mode.rate;
+				dm.dmFields |= DM_DISPLAYFREQUENCY;
+			}
+			if (wnd_data[0].mode.bpp > 0) {
+				dm.dmBitsPerPel = (DWORD)wnd_data[0].mode.bpp;
+				dm.dmFields |= DM_BITSPERPEL;
+			}
+			result = ChangeDisplaySettingsEx(mi.szDevice, &dm, NULL, CDS_FULLSCREEN, NULL);
+			if (result == DISP_CHANGE_SUCCESSFUL) {
+				memcpy(wnd_data[0].mode.device, mi.szDevice, sizeof(mi.szDevice));
+				wnd_data[0].mode.changed = 1;
+				modes_changed++;
+			}
+		} else {
+			result = DISP_CHANGE_FAILED;
+		}
+	}
+	return result;
+}
+
+static void video_mode_restore(window_data_t *const wnd_data) {
+	if (wnd_data[0].mode.changed) {
+		ChangeDisplaySettingsEx(wnd_data[0].mode.device, NULL, NULL, 0, NULL);
+		wnd_data[0].mode.changed = 0;
+		modes_changed--;
+	}
+}
+
+void g2d_video_modes(const int mn, const int max, int *const n, int *const modes) {
+	MONITORINFOEX mi; ZeroMemory(&mi, sizeof(mi)); mi.cbSize = sizeof(mi);
+	n[0] = 0;
+	if (GetMonitorInfo(monitor_by_index(mn), (LPMONITORINFO)&mi)) {
+		DWORD i;
+		DEVMODE dm; ZeroMemory(&dm, sizeof(dm)); dm.dmSize = sizeof(dm);
+		for (i = 0; n[0] < max && EnumDisplaySettings(mi.szDevice, i, &dm); i++) {
+			if (dm.dmBitsPerPel >= 16) {
+				int k, found = 0;
+				for (k = 0; k < n[0] && !found; k++) {
+					int *const mode = &modes[k*4];
+					found = mode[0] == (int)dm.dmPelsWidth && mode[1] == (int)dm.dmPelsHeight && mode[2] == (int)dm.dmDisplayFrequency && mode[3] == (int)dm.dmBitsPerPel;
+				}
+				if (!found) {
+					int *const mode = &modes[n[0]*4];
+					mode[0] = (int)dm.dmPelsWidth;
+					mode[1] = (int)dm.dmPelsHeight;
+					mode[2] = (int)dm.dmDisplayFrequency;
+					mode[3] = (int)dm.dmBitsPerPel;
+					n[0]++;
+				}
+			}
+		}
+	}
+}
+
+void g2d_monitors(const int max, int *const n, int *const rects, int *const rates, float *const scales, int *const primary, char *const names, const int name_len) {
+	int i; monitors_t monitors; monitors_enum(&monitors);
+	n[0] = 0;
+	for (i = 0; i < monitors.count && n[0] < max; i++) {
+		MONITORINFOEX mi; ZeroMemory(&mi, sizeof(mi)); mi.cbSize = sizeof(mi);
+		if (GetMonitorInfo(monitors.hndl[i], (LPMONITORINFO)&mi)) {
+			DEVMODE dm; ZeroMemory(&dm, sizeof(dm)); dm.dmSize = sizeof(dm);
+			DISPLAY_DEVICE dd; ZeroMemory(&dd, sizeof(dd)); dd.cb = sizeof(dd);
+			int *const rect = &rects[n[0]*8];
+			rect[0] = mi.rcMonitor.left; rect[1] = mi.rcMonitor.top;
+			rect[2] = mi.rcMonitor.right - mi.rcMonitor.left; rect[3] = mi.rcMonitor.bottom - mi.rcMonitor.top;
+			rect[4] = mi.rcWork.left; rect[5] = mi.rcWork.top;
+			rect[6] = mi.rcWork.right - mi.rcWork.left; rect[7] = mi.rcWork.bottom - mi.rcWork.top;
+			rates[n[0]] = EnumDisplaySettings(mi.szDevice, ENUM_CURRENT_SETTINGS, &dm) ? (int)dm.dmDisplayFrequency : 0;
+			scales[n[0]] = monitor_scale(monitors.hndl[i]);
+			primary[n[0]] = (mi.dwFlags & MONITORINFOF_PRIMARY) ? 1 : 0;
+			/* friendly name of the attached display, otherwise device name */
+			if (EnumDisplayDevices(mi.szDevice, 0, &dd, 0) && dd.DeviceString[0])
+				tstr_to_utf8(dd.DeviceString, &names[n[0]*name_len], name_len);
+			else
+				tstr_to_utf8(mi.szDevice, &names[n[0]*name_len], name_len);
+			n[0]++;
+		}
+	}
+}
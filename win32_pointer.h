@@ -0,0 +1,62 @@
+/*
+ *          Copyright 2025, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+/* phases are TouchBegin, TouchMove, TouchEnd and TouchCancel in g2d.go */
+static int pointer_phase(const UINT message, const POINTER_FLAGS flags) {
+	/* contact lost (e.g. capture taken by other window) */
+	if ((flags & POINTER_FLAG_CANCELED) || message == WM_POINTERCAPTURECHANGED)
+		return 3;
+	if (message == WM_POINTERLEAVE)
+		return (flags & POINTER_FLAG_INCONTACT) ? 3 : 2;
+	if (message == WM_POINTERDOWN)
+		return 0;
+	if (message == WM_POINTERUP)
+		return 2;
+	return 1;
+}
+
+/* Returns TRUE, if message has been processed as touch or pen input. */
+static BOOL pointer_process(window_data_t *const wnd_data, const UINT message, const WPARAM wParam) {
+	POINTER_INPUT_TYPE type;
+	const UINT32 id = (UINT32)GET_POINTERID_WPARAM(wParam);
+	if (get_pointer_type && get_pointer_type(id, &type)) {
+		if (type == PT_TOUCH && get_pointer_touch_info) {
+			POINTER_TOUCH_INFO info;
+			if (get_pointer_touch_info(id, &info)) {
+				/* touch leaves after WM_POINTERUP, i.e. it has ended already */
+				if (message == WM_POINTERLEAVE && !(info.pointerInfo.pointerFlags & POINTER_FLAG_INCONTACT))
+					return TRUE;
+				POINT point = info.pointerInfo.ptPixelLocation;
+				const int phase = pointer_phase(message, info.pointerInfo.pointerFlags);
+				const float pressure = (info.touchMask & TOUCH_MASK_PRESSURE) ? (float)info.pressure / 1024.0f : 1.0f;
+				ScreenToClient(wnd_data[0].wnd.hndl, &point);
+				g2dTouch(wnd_data[0].cb_id, (int)id, phase, (int)point.x, (int)point.y, pressure);
+				return TRUE;
+			}
+		} else if (type == PT_PEN && get_pointer_pen_info) {
+			POINTER_PEN_INFO info;
+			if (get_pointer_pen_info(id, &info)) {
+				POINT point = info.pointerInfo.ptPixelLocation;
+				const int phase = pointer_phase(message, info.pointerInfo.pointerFlags);
+				const int in_contact = (info.pointerInfo.pointerFlags & POINTER_FLAG_INCONTACT) != 0;
+				const float pressure = (info.penMask & PEN_MASK_PRESSURE) ? (float)info.pressure / 1024.0f : (float)in_contact;
+				const float tilt_x = (info.penMask & PEN_MASK_TILT_X) ? (float)info.tiltX : 0.0f;
+				const float tilt_y = (info.penMask & PEN_MASK_TILT_Y) ? (float)info.tiltY : 0.0f;
+				const int eraser = (info.penFlags & (PEN_FLAG_ERASER | PEN_FLAG_INVERTED)) != 0;
+				const int barrel = (info.penFlags & PEN_FLAG_BARREL) != 0;
+				ScreenToClient(wnd_data[0].wnd.hndl, &point);
+				g2dPen(wnd_data[0].cb_id, (int)id, phase, (int)point.x, (int)point.y, pressure, tilt_x, tilt_y, eraser, barrel);
+				return TRUE;
+			}
+		}
+	} else if (message == WM_POINTERCAPTURECHANGED) {
+		/* pointer info may be gone already, tracked touch is removed anyway */
+		g2dTouch(wnd_data[0].cb_id, (int)id, 3, 0, 0, 0.0f);
+		return TRUE;
+	}
+	return FALSE;
+}
@@ -21,14 +21,121 @@ static LPTSTR to_tstr(void *const go_cstr, const size_t length) {
 
 static void window_metrics(window_data_t *const wnd_data, int *const x, int *const y, int *const w, int *const h) {
 	RECT rect = { wnd_data[0].client.x, wnd_data[0].client.y, wnd_data[0].client.x + wnd_data[0].client.width, wnd_data[0].client.y + wnd_data[0].client.height };
-	AdjustWindowRect(&rect, wnd_data[0].config.style, FALSE);
+	if (adjust_window_rect_ex_for_dpi && wnd_data[0].state.dpi > 0)
+		adjust_window_rect_ex_for_dpi(&rect, wnd_data[0].config.style, FALSE, 0, (UINT)wnd_data[0].state.dpi);
+	else
+		AdjustWindowRect(&rect, wnd_data[0].config.style, FALSE);
 	x[0] = rect.left; y[0] = rect.top; w[0] = rect.right - rect.left; h[0] = rect.bottom - rect.top;
 }
 
-static void monitor_metrics(HMONITOR const monitor, int *const x, int *const y, int *const w, int *const h) {
-	MONITORINFO mi = { sizeof(mi) }; GetMonitorInfo(monitor, &mi);
-	x[0] = mi.rcMonitor.left; y[0] = mi.rcMonitor.top;
-	w[0] = mi.rcMonitor.right - mi.rcMonitor.left; h[0] = mi.rcMonitor.bottom - mi.rcMonitor.top;
+/* best fitting image is the smallest not smaller than size, otherwise the largest */
+static HICON icon_create(const int *const dims, const unsigned char *const pixels, const int n, const int size) {
+	HICON icon = NULL;
+	int i, best = -1, offset = 0, best_offset = 0;
+	for (i = 0; i < n; i++) {
+		const int w = dims[i*2], bw = best >= 0 ? dims[best*2] : 0;
+		if (best < 0 || (w >= size && (bw < size || w < bw)) || (w < size && bw < size && w > bw)) {
+			best = i; best_offset = offset;
+		}
+		offset += dims[i*2] * dims[i*2+1] * 4;
+	}
+	if (best >= 0) {
+		const int w = dims[best*2], h = dims[best*2+1];
+		unsigned char *target = NULL;
+		BITMAPV5HEADER bi; ZeroMemory(&bi, sizeof(bi));
+		bi.bV5Size = sizeof(bi);
+		bi.bV5Width = w;
+		bi.bV5Height = -h;
+		bi.bV5Planes = 1;
+		bi.bV5BitCount = 32;
+		bi.bV5Compression = BI_BITFIELDS;
+		bi.bV5RedMask = 0x00ff0000;
+		bi.bV5GreenMask = 0x0000ff00;
+		bi.bV5BlueMask = 0x000000ff;
+		bi.bV5AlphaMask = 0xff000000;
+		HDC const dc = GetDC(NULL);
+		HBITMAP const color = CreateDIBSection(dc, (BITMAPINFO*)&bi, DIB_RGB_COLORS, (void**)&target, NULL, 0);
+		ReleaseDC(NULL, dc);
+		if (color) {
+			HBITMAP const mask = CreateBitmap(w, h, 1, 1, NULL);
+			if (mask) {
+				ICONINFO ii; ZeroMemory(&ii, sizeof(ii));
+				const unsigned char *const source = &pixels[best_offset];
+				/* RGBA to BGRA */
+				for (i = 0; i < w * h; i++) {
+					target[i*4+0] = source[i*4+2];
+					target[i*4+1] = source[i*4+1];
+					target[i*4+2] = source[i*4+0];
+					target[i*4+3] = source[i*4+3];
+				}
+				ii.fIcon = TRUE;
+				ii.hbmMask = mask;
+				ii.hbmColor = color;
+				icon = CreateIconIndirect(&ii);
+				DeleteObject(mask);
+			}
+			DeleteObject(color);
+		}
+	}
+	return icon;
+}
+
+static void icons_update(window_data_t *const wnd_data, const int *const dims, const unsigned char *const pixels, const int n) {
+	HICON const big = icon_create(dims, pixels, n, GetSystemMetrics(SM_CXICON));
+	HICON const small = icon_create(dims, pixels, n, GetSystemMetrics(SM_CXSMICON));
+	SendMessage(wnd_data[0].wnd.hndl, WM_SETICON, ICON_BIG, (LPARAM)big);
+	SendMessage(wnd_data[0].wnd.hndl, WM_SETICON, ICON_SMALL, (LPARAM)small);
+	if (wnd_data[0].icon.big)
+		DestroyIcon(wnd_data[0].icon.big);
+	if (wnd_data[0].icon.small)
+		DestroyIcon(wnd_data[0].icon.small);
+	wnd_data[0].icon.big = big;
+	wnd_data[0].icon.small = small;
+}
+
+static void attributes_update(window_data_t *const wnd_data, const int t, const float o, const int s, const int a) {
+	HWND const hndl = wnd_data[0].wnd.hndl;
+	LONG_PTR ex_style = GetWindowLongPtr(hndl, GWL_EXSTYLE);
+	const float opacity = o < 0.0f ? 0.0f : (o > 1.0f ? 1.0f : o);
+	if (wnd_data[0].config.topmost != t) {
+		wnd_data[0].config.topmost = t;
+		SetWindowPos(hndl, t ? HWND_TOPMOST : HWND_NOTOPMOST, 0, 0, 0, 0, SWP_NOMOVE | SWP_NOSIZE | SWP_NOACTIVATE | SWP_NOOWNERZORDER);
+	}
+	if (wnd_data[0].config.opacity != opacity) {
+		wnd_data[0].config.opacity = opacity;
+		if (opacity < 1.0f) {
+			ex_style |= WS_EX_LAYERED;
+			SetWindowLongPtr(hndl, GWL_EXSTYLE, ex_style);
+			SetLayeredWindowAttributes(hndl, 0, (BYTE)(opacity * 255.0f + 0.5f), LWA_ALPHA);
+		} else {
+			ex_style &= ~WS_EX_LAYERED;
+			SetWindowLongPtr(hndl, GWL_EXSTYLE, ex_style);
+		}
+	}
+	if (wnd_data[0].config.skip_taskbar != s) {
+		wnd_data[0].config.skip_taskbar = s;
+		if (s)
+			ex_style = (ex_style | WS_EX_TOOLWINDOW) & ~WS_EX_APPWINDOW;
+		else
+			ex_style &= ~WS_EX_TOOLWINDOW;
+		/* taskbar is updated, when window is shown again */
+		if (wnd_data[0].state.shown)
+			ShowWindow(hndl, SW_HIDE);
+		SetWindowLongPtr(hndl, GWL_EXSTYLE, ex_style);
+		if (wnd_data[0].state.shown)
+			ShowWindow(hndl, SW_SHOW);
+	}
+	if (a && !wnd_data[0].config.attention) {
+		if (GetForegroundWindow() != hndl) {
+			FLASHWINFO fi = { sizeof(FLASHWINFO), hndl, FLASHW_ALL | FLASHW_TIMERNOFG, 0, 0 };
+			FlashWindowEx(&fi);
+			wnd_data[0].config.attention = 1;
+		}
+	} else if (!a && wnd_data[0].config.attention) {
+		FLASHWINFO fi = { sizeof(FLASHWINFO), hndl, FLASHW_STOP, 0, 0 };
+		FlashWindowEx(&fi);
+		wnd_data[0].config.attention = 0;
+	}
 }
 
 static void style_update(window_data_t *const wnd_data) {
@@ -54,6 +161,42 @@ static void mouse_update(window_data_t *const wnd_data) {
 	}
 }
 
+/* HitClient, HitCaption, etc. in g2d.go */
+static const LRESULT hit_results[] = { HTCLIENT, HTCAPTION, HTLEFT, HTRIGHT, HTTOP, HTTOPLEFT, HTTOPRIGHT, HTBOTTOM, HTBOTTOMLEFT, HTBOTTOMRIGHT };
+
+/* first region containing the point determines the result */
+static void hit_regions_test(window_data_t *const wnd_data, const LPARAM lParam, LRESULT *const result) {
+	if (wnd_data[0].hit.n > 0) {
+		POINT point = { (LONG)(short)LOWORD(lParam), (LONG)(short)HIWORD(lParam) };
+		if (ScreenToClient(wnd_data[0].wnd.hndl, &point)) {
+			int i;
+			for (i = 0; i < wnd_data[0].hit.n; i++) {
+				const int *const region = &wnd_data[0].hit.regions[i*5];
+				if (point.x >= region[0] && point.y >= region[1] && point.x < region[0] + region[2] && point.y < region[1] + region[3]) {
+					result[0] = hit_results[region[4]];
+					break;
+				}
+			}
+		}
+	}
+}
+
+/* tracks enter/leave of client area (leave is also detected, while mouse is captured) */
+static void mouse_inside_update(window_data_t *const wnd_data, const DWORD flags) {
+	const int inside = wnd_data[0].mouse.x >= 0 && wnd_data[0].mouse.y >= 0 && wnd_data[0].mouse.x < wnd_data[0].client.width && wnd_data[0].mouse.y < wnd_data[0].client.height;
+	if (inside) {
+		TRACKMOUSEEVENT tme = { sizeof(TRACKMOUSEEVENT), TME_LEAVE | flags, wnd_data[0].wnd.hndl, 0 };
+		TrackMouseEvent(&tme);
+		if (!wnd_data[0].mouse.inside) {
+			wnd_data[0].mouse.inside = 1;
+			g2dMouseEnter(wnd_data[0].cb_id);
+		}
+	} else if (wnd_data[0].mouse.inside) {
+		wnd_data[0].mouse.inside = 0;
+		g2dMouseLeave(wnd_data[0].cb_id);
+	}
+}
+
 static void client_props_update(window_data_t *const wnd_data) {
 	RECT rect;
 	POINT point = {0, 0};
@@ -65,6 +208,31 @@ static void client_props_update(window_data_t *const wnd_data) {
 	wnd_data[0].client.height = (int)(rect.bottom - rect.top);
 }
 
+/* client area, when window is neither minimized, maximized nor fullscreen */
+static void client_normal(window_data_t *const wnd_data, int *const x, int *const y, int *const w, int *const h) {
+	WINDOWPLACEMENT placement = { sizeof(placement) };
+	x[0] = wnd_data[0].client.x; y[0] = wnd_data[0].client.y;
+	w[0] = wnd_data[0].client.width; h[0] = wnd_data[0].client.height;
+	if (wnd_data[0].config.fullscreen) {
+		x[0] = wnd_data[0].client_bak.x; y[0] = wnd_data[0].client_bak.y;
+		w[0] = wnd_data[0].client_bak.width; h[0] = wnd_data[0].client_bak.height;
+	} else if ((wnd_data[0].state.minimized || wnd_data[0].state.maximized) && GetWindowPlacement(wnd_data[0].wnd.hndl, &placement)) {
+		RECT rect = placement.rcNormalPosition, frame = { 0, 0, 0, 0 };
+		MONITORINFO mi = { sizeof(mi) };
+		/* workspace coordinates to screen coordinates */
+		if (!(GetWindowLongPtr(wnd_data[0].wnd.hndl, GWL_EXSTYLE) & WS_EX_TOOLWINDOW) && GetMonitorInfo(MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST), &mi))
+			OffsetRect(&rect, mi.rcWork.left - mi.rcMonitor.left, mi.rcWork.top - mi.rcMonitor.top);
+		/* window rect to client rect */
+		if (adjust_window_rect_ex_for_dpi && wnd_data[0].state.dpi > 0)
+			adjust_window_rect_ex_for_dpi(&frame, wnd_data[0].config.style, FALSE, 0, (UINT)wnd_data[0].state.dpi);
+		else
+			AdjustWindowRect(&frame, wnd_data[0].config.style, FALSE);
+		x[0] = rect.left - frame.left; y[0] = rect.top - frame.top;
+		w[0] = (rect.right - rect.left) - (frame.right - frame.left);
+		h[0] = (rect.bottom - rect.top) - (frame.bottom - frame.top);
+	}
+}
+
 static void cursor_clip_update(window_data_t *const wnd_data) {
 	if (wnd_data[0].config.locked && !wnd_data[0].config.dragable) {
 		const RECT rect = { wnd_data[0].client.x, wnd_data[0].client.y, wnd_data[0].client.x + wnd_data[0].client.width, wnd_data[0].client.y + wnd_data[0].client.height };
@@ -73,16 +241,167 @@ static void cursor_clip_update(window_data_t *const wnd_data) {
 	}
 }
 
-static void button_down(window_data_t *const wnd_data, const int button_idx, const int double_click) {
-	g2dButtonDown(wnd_data[0].cb_id, button_idx, double_click);
-	wnd_data[0].mouse.double_clicked[button_idx] = double_click;
-	SetCapture(wnd_data[0].wnd.hndl);
+/* bitmask of ModShift, ModCtrl, ModAlt and ModSuper in g2d.go */
+static int modifiers() {
+	int mods = 0;
+	if (GetKeyState(VK_SHIFT) & 0x8000)
+		mods |= 1;
+	if (GetKeyState(VK_CONTROL) & 0x8000)
+		mods |= 2;
+	if (GetKeyState(VK_MENU) & 0x8000)
+		mods |= 4;
+	if ((GetKeyState(VK_LWIN) | GetKeyState(VK_RWIN)) & 0x8000)
+		mods |= 8;
+	return mods;
 }
 
-static void button_up(window_data_t *const wnd_data, const int button_idx) {
-	g2dButtonUp(wnd_data[0].cb_id, button_idx, wnd_data[0].mouse.double_clicked[button_idx]);
-	wnd_data[0].mouse.double_clicked[button_idx] = 0;
-	ReleaseCapture();
+static void button_down(window_data_t *const wnd_data, const int button_idx, const LPARAM lParam) {
+	const int x = ((int)(short)LOWORD(lParam)), y = ((int)(short)HIWORD(lParam));
+	const DWORD time = (DWORD)GetMessageTime();
+	const int dx = x - wnd_data[0].mouse.click_x, dy = y - wnd_data[0].mouse.click_y;
+	const int dx_max = GetSystemMetrics(SM_CXDOUBLECLK) / 2, dy_max = GetSystemMetrics(SM_CYDOUBLECLK) / 2;
+	if (button_idx == wnd_data[0].mouse.click_button && time - wnd_data[0].mouse.click_time <= wnd_data[0].mouse.click_interval &&
+		dx >= -dx_max && dx <= dx_max && dy >= -dy_max && dy <= dy_max)
+		wnd_data[0].mouse.clicks[button_idx] = wnd_data[0].mouse.click_count + 1;
+	else
+		wnd_data[0].mouse.clicks[button_idx] = 1;
+	wnd_data[0].mouse.click_count = wnd_data[0].mouse.clicks[button_idx];
+	wnd_data[0].mouse.click_button = button_idx;
+	wnd_data[0].mouse.click_time = time;
+	wnd_data[0].mouse.click_x = x;
+	wnd_data[0].mouse.click_y = y;
+	wnd_data[0].mouse.x = x;
+	wnd_data[0].mouse.y = y;
+	/* capture mouse, while a button is pressed */
+	if (wnd_data[0].mouse.buttons == 0)
+		SetCapture(wnd_data[0].wnd.hndl);
+	wnd_data[0].mouse.buttons |= 1 << button_idx;
+	g2dButtonDown(wnd_data[0].cb_id, button_idx, x, y, wnd_data[0].mouse.clicks[button_idx], modifiers());
+}
+
+static void button_up(window_data_t *const wnd_data, const int button_idx, const int x, const int y) {
+	const int clicks = wnd_data[0].mouse.clicks[button_idx];
+	wnd_data[0].mouse.clicks[button_idx] = 0;
+	wnd_data[0].mouse.x = x;
+	wnd_data[0].mouse.y = y;
+	wnd_data[0].mouse.buttons &= ~(1 << button_idx);
+	if (wnd_data[0].mouse.buttons == 0)
+		ReleaseCapture();
+	g2dButtonUp(wnd_data[0].cb_id, button_idx, x, y, clicks, modifiers());
+}
+
+/* releases all buttons after capture has been taken by another window */
+static void buttons_release(window_data_t *const wnd_data) {
+	int i; const int buttons = wnd_data[0].mouse.buttons;
+	wnd_data[0].mouse.buttons = 0;
+	for (i = 0; i < 5; i++) {
+		if (buttons & (1 << i)) {
+			const int clicks = wnd_data[0].mouse.clicks[i];
+			wnd_data[0].mouse.clicks[i] = 0;
+			g2dButtonUp(wnd_data[0].cb_id, i, wnd_data[0].mouse.x, wnd_data[0].mouse.y, clicks, modifiers());
+		}
+	}
+}
+
+/* sets display mode and covers monitor (window is fullscreen) */
+static void video_mode_apply(window_data_t *const wnd_data, long long *const err1, long long *const err2) {
+	video_mode_restore(wnd_data);
+	if (wnd_data[0].mode.exclusive) {
+		const LONG result = video_mode_change(wnd_data, MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST));
+		if (result != DISP_CHANGE_SUCCESSFUL) {
+			err1[0] = G2D_ERR_1001024; err2[0] = (long long)result;
+		}
+	}
+	if (err1[0] == 0) {
+		int mx, my, mw, mh; monitor_metrics(MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST), &mx, &my, &mw, &mh);
+		HWND const insert_after = wnd_data[0].mode.exclusive ? HWND_TOPMOST : HWND_TOP;
+		if (SetWindowPos(wnd_data[0].wnd.hndl, insert_after, mx, my, mw, mh, SWP_NOOWNERZORDER | SWP_SHOWWINDOW)) {
+			client_props_update(wnd_data);
+			cursor_clip_update(wnd_data);
+		} else {
+			err1[0] = G2D_ERR_1001025; err2[0] = (long long)GetLastError();
+		}
+	}
+}
+
+/* visible bounds without drop shadow, if DWM is available */
+static BOOL window_bounds(HWND const hndl, RECT *const rect) {
+	if (dwm_get_window_attribute && dwm_get_window_attribute(hndl, 9 /* DWMWA_EXTENDED_FRAME_BOUNDS */, rect, sizeof(RECT)) == S_OK)
+		return TRUE;
+	return GetWindowRect(hndl, rect);
+}
+
+/* cloaked windows are e.g. on another virtual desktop */
+static int window_hidden(HWND const hndl) {
+	DWORD cloaked = 0;
+	if (!IsWindowVisible(hndl) || IsIconic(hndl))
+		return 1;
+	if (dwm_get_window_attribute && dwm_get_window_attribute(hndl, 14 /* DWMWA_CLOAKED */, &cloaked, sizeof(DWORD)) == S_OK)
+		return cloaked != 0;
+	return 0;
+}
+
+/* window is occluded, if it is hidden or covered by opaque windows above it */
+static int window_occluded(window_data_t *const wnd_data) {
+	int occluded = 1;
+	RECT rect;
+	if (!window_hidden(wnd_data[0].wnd.hndl) && window_bounds(wnd_data[0].wnd.hndl, &rect)) {
+		HRGN const visible = CreateRectRgnIndirect(&rect);
+		HRGN const cover = CreateRectRgn(GetSystemMetrics(SM_XVIRTUALSCREEN), GetSystemMetrics(SM_YVIRTUALSCREEN),
+			GetSystemMetrics(SM_XVIRTUALSCREEN) + GetSystemMetrics(SM_CXVIRTUALSCREEN), GetSystemMetrics(SM_YVIRTUALSCREEN) + GetSystemMetrics(SM_CYVIRTUALSCREEN));
+		if (visible && cover) {
+			HWND above = GetWindow(wnd_data[0].wnd.hndl, GW_HWNDPREV);
+			int region = CombineRgn(visible, visible, cover, RGN_AND);
+			while (above && region != NULLREGION && region != ERROR) {
+				/* layered and transparent windows might not cover */
+				const LONG_PTR ex_style = GetWindowLongPtr(above, GWL_EXSTYLE);
+				if (!(ex_style & (WS_EX_LAYERED | WS_EX_TRANSPARENT)) && !window_hidden(above) && window_bounds(above, &rect)) {
+					SetRectRgn(cover, rect.left, rect.top, rect.right, rect.bottom);
+					region = CombineRgn(visible, visible, cover, RGN_DIFF);
+				}
+				above = GetWindow(above, GW_HWNDPREV);
+			}
+			occluded = region == NULLREGION;
+		} else {
+			occluded = 0;
+		}
+		if (visible)
+			DeleteObject(visible);
+		if (cover)
+			DeleteObject(cover);
+	}
+	return occluded;
+}
+
+static void occlusion_update(window_data_t *const wnd_data) {
+	const int occluded = window_occluded(wnd_data);
+	if (wnd_data[0].state.occluded != occluded) {
+		wnd_data[0].state.occluded = occluded;
+		g2dOccluded(wnd_data[0].cb_id, occluded);
+	}
+}
+
+/* end of session, suspend/resume and occlusion (also while minimized) */
+static void session_process(window_data_t *const wnd_data, const UINT message, const WPARAM wParam) {
+	if (message == WM_QUERYENDSESSION) {
+		/* returns after OnEndSession (or time out) */
+		g2dEndSession(wnd_data[0].cb_id);
+	} else if (message == WM_POWERBROADCAST) {
+		if (wParam == PBT_APMSUSPEND)
+			g2dSuspend(wnd_data[0].cb_id);
+		else if (wParam == PBT_APMRESUMEAUTOMATIC)
+			g2dResume(wnd_data[0].cb_id);
+	} else if (message == WM_TIMER && wParam == G2D_OCCLUSION_TIMER) {
+		occlusion_update(wnd_data);
+	}
+}
+
+/* new size is suggested by system */
+static void dpi_update(window_data_t *const wnd_data, const int dpi, const RECT *const rect) {
+	wnd_data[0].state.dpi = dpi;
+	if (!wnd_data[0].config.fullscreen)
+		SetWindowPos(wnd_data[0].wnd.hndl, NULL, rect[0].left, rect[0].top, rect[0].right - rect[0].left, rect[0].bottom - rect[0].top, SWP_NOZORDER | SWP_NOACTIVATE);
+	g2dContentScale(wnd_data[0].cb_id, (float)dpi / 96.0f);
 }
 
 static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARAM lParam) {
@@ -95,11 +414,13 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 	} else {
 		window_data_t *const wnd_data = (window_data_t*)GetWindowLongPtr(hWnd, GWLP_USERDATA);
 		if (wnd_data) {
+			session_process(wnd_data, message, wParam);
 			if (!wnd_data[0].state.minimized) {
 				switch (message) {
 				case WM_MOVE:
 					if (wnd_data[0].state.shown) {
 						client_props_update(wnd_data);
+						monitor_update(wnd_data);
 						g2dWindowMove(wnd_data[0].cb_id);
 					}
 					result = DefWindowProc(hWnd, message, wParam, lParam);
@@ -107,11 +428,20 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 				case WM_SIZE:
 					if (wnd_data[0].state.shown) {
 						client_props_update(wnd_data);
+						monitor_update(wnd_data);
 						g2dWindowResize(wnd_data[0].cb_id);
+						if (wParam == SIZE_MAXIMIZED && !wnd_data[0].state.maximized) {
+							wnd_data[0].state.maximized = 1;
+							g2dWindowMaximize(wnd_data[0].cb_id);
+						} else if (wParam == SIZE_RESTORED && wnd_data[0].state.maximized) {
+							wnd_data[0].state.maximized = 0;
+							g2dWindowRestore(wnd_data[0].cb_id);
+						}
 					}
 					result = DefWindowProc(hWnd, message, wParam, lParam);
 					break;
 				case WM_SETFOCUS:
+					wnd_data[0].config.attention = 0;
 					if (wnd_data[0].state.shown) {
 						result = DefWindowProc(hWnd, WM_NCHITTEST, wParam, lParam);
 						if (result == HTCLIENT) {
@@ -126,6 +456,11 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 					break;
 				case WM_KILLFOCUS:
 					wnd_data[0].state.focus = 0;
+					/* exclusive fullscreen gives desktop back, when focus is lost */
+					if (wnd_data[0].mode.changed) {
+						video_mode_restore(wnd_data);
+						PostMessage(hWnd, WM_SYSCOMMAND, SC_MINIMIZE, 0);
+					}
 					g2dOnFocus(wnd_data[0].cb_id, 0);
 					result = DefWindowProc(hWnd, message, wParam, lParam);
 					break;
@@ -160,6 +495,7 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 									result = HTBOTTOMRIGHT;
 							}
 						}
+						hit_regions_test(wnd_data, lParam, &result);
 					}
 					break;
 				case WM_NCMOUSEMOVE:
@@ -170,11 +506,20 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 							wnd_data[0].state.dragging = 0;
 						} else {
 							mouse_update(wnd_data);
+							mouse_inside_update(wnd_data, TME_NONCLIENT);
 							g2dMouseMove(wnd_data[0].cb_id);
 						}
 						result = DefWindowProc(hWnd, message, wParam, lParam);
 					}
 					break;
+				case WM_MOUSELEAVE:
+				case WM_NCMOUSELEAVE:
+					if (wnd_data[0].mouse.inside && !wnd_data[0].mouse.buttons) {
+						wnd_data[0].mouse.inside = 0;
+						g2dMouseLeave(wnd_data[0].cb_id);
+					}
+					result = DefWindowProc(hWnd, message, wParam, lParam);
+					break;
 				case WM_NCLBUTTONDOWN:
 					result = DefWindowProc(hWnd, WM_NCHITTEST, wParam, lParam);
 					if (result == HTCLIENT)
@@ -207,68 +552,89 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 				case WM_MOUSEMOVE:
 					wnd_data[0].mouse.x = ((int)(short)LOWORD(lParam));
 					wnd_data[0].mouse.y = ((int)(short)HIWORD(lParam));
+					mouse_inside_update(wnd_data, 0);
 					g2dMouseMove(wnd_data[0].cb_id);
 					if (wnd_data[0].state.focus == 2)
 						cursor_clip_update(wnd_data);
 					result = DefWindowProc(hWnd, message, wParam, lParam);
 					break;
 				case WM_LBUTTONDOWN:
-					button_down(wnd_data, 0, 0);
+				case WM_LBUTTONDBLCLK:
+					button_down(wnd_data, 0, lParam);
 					break;
 				case WM_LBUTTONUP:
-					button_up(wnd_data, 0);
-					break;
-				case WM_LBUTTONDBLCLK:
-					button_down(wnd_data, 0, 1);
+					button_up(wnd_data, 0, ((int)(short)LOWORD(lParam)), ((int)(short)HIWORD(lParam)));
 					break;
 				case WM_RBUTTONDOWN:
-					button_down(wnd_data, 1, 0);
+				case WM_RBUTTONDBLCLK:
+					button_down(wnd_data, 1, lParam);
 					break;
 				case WM_RBUTTONUP:
-					button_up(wnd_data, 1);
-					break;
-				case WM_RBUTTONDBLCLK:
-					button_down(wnd_data, 1, 1);
+					button_up(wnd_data, 1, ((int)(short)LOWORD(lParam)), ((int)(short)HIWORD(lParam)));
 					break;
 				case WM_MBUTTONDOWN:
-					button_down(wnd_data, 2, 0);
+				case WM_MBUTTONDBLCLK:
+					button_down(wnd_data, 2, lParam);
 					break;
 				case WM_MBUTTONUP:
-					button_up(wnd_data, 2);
-					break;
-				case WM_MBUTTONDBLCLK:
-					button_down(wnd_data, 2, 1);
+					button_up(wnd_data, 2, ((int)(short)LOWORD(lParam)), ((int)(short)HIWORD(lParam)));
 					break;
 				case WM_MOUSEWHEEL:
 					g2dWheel(wnd_data[0].cb_id, (float)GET_WHEEL_DELTA_WPARAM(wParam) / (float)WHEEL_DELTA);
 					break;
+				case WM_POINTERDOWN:
+				case WM_POINTERUPDATE:
+				case WM_POINTERUP:
+				case WM_POINTERLEAVE:
+				case WM_POINTERCAPTURECHANGED:
+					if (!pointer_process(wnd_data, message, wParam))
+						result = DefWindowProc(hWnd, message, wParam, lParam);
+					break;
+				/* X1 is "back" (3) and X2 is "forward" (4) */
 				case WM_XBUTTONDOWN:
+				case WM_XBUTTONDBLCLK:
 					if (HIWORD(wParam) == XBUTTON1)
-						button_down(wnd_data, 3, 0);
+						button_down(wnd_data, 3, lParam);
 					else if (HIWORD(wParam) == XBUTTON2)
-						button_down(wnd_data, 4, 0);
+						button_down(wnd_data, 4, lParam);
+					result = TRUE;
 					break;
 				case WM_XBUTTONUP:
 					if (HIWORD(wParam) == XBUTTON1)
-						button_up(wnd_data, 3);
+						button_up(wnd_data, 3, ((int)(short)LOWORD(lParam)), ((int)(short)HIWORD(lParam)));
 					else if (HIWORD(wParam) == XBUTTON2)
-						button_up(wnd_data, 4);
+						button_up(wnd_data, 4, ((int)(short)LOWORD(lParam)), ((int)(short)HIWORD(lParam)));
+					result = TRUE;
 					break;
-				case WM_XBUTTONDBLCLK:
-					if (HIWORD(wParam) == XBUTTON1)
-						button_down(wnd_data, 3, 1);
-					else if (HIWORD(wParam) == XBUTTON2)
-						button_down(wnd_data, 4, 1);
+				case WM_DPICHANGED:
+					dpi_update(wnd_data, (int)LOWORD(wParam), (RECT*)lParam);
+					break;
+				case WM_DISPLAYCHANGE:
+					monitor_update(wnd_data);
+					g2dMonitorChanged(wnd_data[0].cb_id);
+					result = DefWindowProc(hWnd, message, wParam, lParam);
+					break;
+				case WM_CAPTURECHANGED:
+					if ((HWND)lParam != hWnd)
+						buttons_release(wnd_data);
+					result = DefWindowProc(hWnd, message, wParam, lParam);
 					break;
 				default:
 					result = DefWindowProc(hWnd, message, wParam, lParam);
 				}
 			} else {
 				result = DefWindowProc(hWnd, message, wParam, lParam);
-				if (message == WM_SETFOCUS) {
+				if (message == WM_DISPLAYCHANGE) {
+					monitor_update(wnd_data);
+					g2dMonitorChanged(wnd_data[0].cb_id);
+				} else if (message == WM_SETFOCUS) {
 					// restore from minimized and avoid move/resize events
 					if (wnd_data[0].state.minimized) {
 						wnd_data[0].state.minimized = 0;
+						if (wnd_data[0].config.fullscreen && wnd_data[0].mode.exclusive) {
+							long long err1 = 0, err2 = 0;
+							video_mode_apply(wnd_data, &err1, &err2);
+						}
 						g2dWindowRestore(wnd_data[0].cb_id);
 					}
 				}
@@ -281,7 +647,7 @@ static LRESULT CALLBACK windowProc(HWND hWnd, UINT message, WPARAM wParam, LPARA
 }
 
 void g2d_window_create(void **const data, const int cb_id, const int x, const int y, const int w, const int h, const int wn, const int hn, const int wx, const int hx,
-	const int b, const int d, const int r, const int f, const int l, const int c, void *const t, const size_t ts, long long *const err1, long long *const err2) {
+	const int b, const int d, const int r, const int f, const int l, const int c, const int ci, const int mn, void *const owner, const int modal, void *const t, const size_t ts, long long *const err1, long long *const err2) {
 	window_data_t *const wnd_data = (window_data_t*)malloc(sizeof(window_data_t));
 	if (wnd_data) {
 		LPCTSTR const title = to_tstr(t, ts);
@@ -301,11 +667,26 @@ void g2d_window_create(void **const data, const int cb_id, const int x, const in
 			wnd_data[0].config.fullscreen = f;
 			wnd_data[0].config.resizable = r;
 			wnd_data[0].config.locked = l;
+			wnd_data[0].mouse.click_button = -1;
+			wnd_data[0].config.opacity = 1.0f;
+			wnd_data[0].owner.hndl = owner ? ((window_data_t*)owner)[0].wnd.hndl : NULL;
+			wnd_data[0].owner.modal = owner ? modal : 0;
+			wnd_data[0].mouse.click_interval = ci > 0 ? (DWORD)ci : GetDoubleClickTime();
+			/* size is in logical units */
+			wnd_data[0].state.dpi = (int)(monitor_scale(monitor_by_index(mn)) * 96.0f + 0.5f);
+			wnd_data[0].client.width = w * wnd_data[0].state.dpi / 96;
+			wnd_data[0].client.height = h * wnd_data[0].state.dpi / 96;
 			style_update(wnd_data);
 			if (c) {
 				int wx, wy, ww, wh, mx, my, mw, mh;
 				window_metrics(wnd_data, &wx, &wy, &ww, &wh);
-				monitor_metrics(MonitorFromWindow(NULL, MONITOR_DEFAULTTOPRIMARY), &mx, &my, &mw, &mh);
+				RECT rect;
+				/* center over owner */
+				if (wnd_data[0].owner.hndl && GetWindowRect(wnd_data[0].owner.hndl, &rect)) {
+					mx = rect.left; my = rect.top; mw = rect.right - rect.left; mh = rect.bottom - rect.top;
+				} else {
+					monitor_metrics(monitor_by_index(mn), &mx, &my, &mw, &mh);
+				}
 				wnd_data[0].client.x = mx + (mw - ww) / 2 + (wnd_data[0].client.x - wx);
 				wnd_data[0].client.y = my + (mh - wh) / 2 + (wnd_data[0].client.y - wy);
 			}
@@ -330,7 +711,7 @@ void g2d_window_create(void **const data, const int cb_id, const int x, const in
 			if (err1[0] == 0) {
 				int x, y, w, h; window_metrics(wnd_data, &x, &y, &w, &h);
 				const DWORD style = wnd_data[0].config.style;
-				wnd_data[0].wnd.hndl = CreateWindow(class_name, title, style, x, y, w, h, NULL, NULL, instance, (LPVOID)wnd_data);
+				wnd_data[0].wnd.hndl = CreateWindow(class_name, title, style, x, y, w, h, wnd_data[0].owner.hndl, NULL, instance, (LPVOID)wnd_data);
 				if (wnd_data[0].wnd.hndl) {
 					wnd_data[0].wnd.dc = GetDC(wnd_data[0].wnd.hndl);
 					if (wnd_data[0].wnd.dc) {
@@ -369,6 +750,8 @@ void g2d_window_create(void **const data, const int cb_id, const int x, const in
 								wnd_data[0].wnd.rc = wglCreateContextAttribsARB(wnd_data[0].wnd.dc, 0, contextAttributes);
 								if (wnd_data[0].wnd.rc) {
 									memcpy(wnd_data[0].gfx.unif_data, default_projection_mat, sizeof(default_projection_mat));
+									monitor_update(wnd_data);
+									wnd_data[0].state.dpi = window_dpi(wnd_data);
 									data[0] = (void*)wnd_data;
 								} else {
 									err1[0] = G2D_ERR_1001006; err2[0] = (long long)GetLastError(); windows_count--;
@@ -406,12 +789,17 @@ void g2d_window_create(void **const data, const int cb_id, const int x, const in
 void g2d_window_show(void *const data, long long *const err1, long long *const err2) {
 	if (data) {
 		window_data_t *const wnd_data = (window_data_t*)data;
-		ShowWindow(wnd_data[0].wnd.hndl, SW_SHOWDEFAULT);
+		ShowWindow(wnd_data[0].wnd.hndl, wnd_data[0].state.maximized ? SW_SHOWMAXIMIZED : SW_SHOWDEFAULT);
+		if (wnd_data[0].state.maximized)
+			client_props_update(wnd_data);
+		if (wnd_data[0].owner.modal)
+			EnableWindow(wnd_data[0].owner.hndl, FALSE);
 		if (wnd_data[0].config.fullscreen)
 			g2d_window_fullscreen_set(wnd_data, err1, err2);
 		if (err1[0] == 0) {
 			cursor_clip_update(wnd_data);
 			wnd_data[0].state.shown = 1;
+			SetTimer(wnd_data[0].wnd.hndl, G2D_OCCLUSION_TIMER, G2D_OCCLUSION_INTERVAL, NULL);
 		} else {
 			if (err1[0] == G2D_ERR_1001007) err1[0] = G2D_ERR_1001011;
 			if (err1[0] == G2D_ERR_1001008) err1[0] = G2D_ERR_1001012;
@@ -424,6 +812,14 @@ void g2d_window_show(void *const data, long long *const err1, long long *const e
 void g2d_window_destroy(void *const data, long long *err1, long long *err2) {
 	if (data) {
 		window_data_t *const wnd_data = (window_data_t*)data;
+		video_mode_restore(wnd_data);
+		/* enable owner before destroying, so that it gets focus */
+		if (wnd_data[0].owner.modal)
+			EnableWindow(wnd_data[0].owner.hndl, TRUE);
+		if (wnd_data[0].icon.big)
+			DestroyIcon(wnd_data[0].icon.big);
+		if (wnd_data[0].icon.small)
+			DestroyIcon(wnd_data[0].icon.small);
 		if (!wglDeleteContext(wnd_data[0].wnd.rc) && err1[0] == 0) {
 			err1[0] = G2D_ERR_1001015; err2[0] = (long long)GetLastError();
 		}
@@ -434,6 +830,8 @@ void g2d_window_destroy(void *const data, long long *err1, long long *err2) {
 		windows_count--;
 		if (wnd_data[0].rects.buffer)
 			free(wnd_data[0].rects.buffer);
+		if (wnd_data[0].fbs.refs)
+			free(wnd_data[0].fbs.refs);
 		free(wnd_data);
 		if (windows_count <= 0) { 
 			if (!UnregisterClass(class_name, instance) && err1[0] == 0) {
@@ -444,11 +842,14 @@ void g2d_window_destroy(void *const data, long long *err1, long long *err2) {
 	}
 }
 
-void g2d_window_props(void *const data, int *const mx, int *const my, int *const x, int *const y, int *const w, int *const h, int *const wn, int *const hn,
-	int *const wx, int *const hx, int *const b, int *const d, int *const r, int *const f, int *const l) {
+void g2d_window_props(void *const data, int *const mx, int *const my, int *const mi, int *const x, int *const y, int *const w, int *const h, int *const wn, int *const hn,
+	int *const wx, int *const hx, int *const b, int *const d, int *const r, int *const f, int *const l, int *const mn,
+	int *const e, int *const vw, int *const vh, int *const vr, int *const vb, float *const cs, int *const sn, int *const sx,
+	int *const t, float *const o, int *const s, int *const a, int *const oc, int *const nx, int *const ny, int *const nw, int *const nh) {
 	window_data_t *const wnd_data = (window_data_t*)data;
 	mx[0] = wnd_data[0].mouse.x;
 	my[0] = wnd_data[0].mouse.y;
+	mi[0] = wnd_data[0].mouse.inside;
 	x[0] = wnd_data[0].client.x;
 	y[0] = wnd_data[0].client.y;
 	w[0] = wnd_data[0].client.width;
@@ -462,6 +863,21 @@ void g2d_window_props(void *const data, int *const mx, int *const my, int *const
 	r[0] = wnd_data[0].config.resizable;
 	f[0] = wnd_data[0].config.fullscreen;
 	l[0] = wnd_data[0].config.locked;
+	mn[0] = wnd_data[0].state.monitor;
+	e[0] = wnd_data[0].mode.exclusive;
+	vw[0] = wnd_data[0].mode.width;
+	vh[0] = wnd_data[0].mode.height;
+	vr[0] = wnd_data[0].mode.rate;
+	vb[0] = wnd_data[0].mode.bpp;
+	cs[0] = (float)wnd_data[0].state.dpi / 96.0f;
+	sn[0] = wnd_data[0].state.minimized;
+	sx[0] = wnd_data[0].state.maximized;
+	t[0] = wnd_data[0].config.topmost;
+	o[0] = wnd_data[0].config.opacity;
+	s[0] = wnd_data[0].config.skip_taskbar;
+	a[0] = wnd_data[0].config.attention;
+	oc[0] = wnd_data[0].state.occluded;
+	client_normal(wnd_data, nx, ny, nw, nh);
 }
 
 void g2d_window_pos_size_set(void *const data, const int x, const int y, const int width, const int height) {
@@ -479,7 +895,8 @@ void g2d_window_pos_size_set(void *const data, const int x, const int y, const i
 	}
 }
 
-void g2d_window_style_set(void *const data, const int wn, const int hn, const int wx, const int hx, const int b, const int d, const int r, const int f, const int l) {
+void g2d_window_style_set(void *const data, const int wn, const int hn, const int wx, const int hx, const int b, const int d, const int r, const int f, const int l,
+	const int t, const float o, const int s, const int a, int *const icon_dims, void *const icon_pixels, const int icon_n) {
 	window_data_t *const wnd_data = (window_data_t*)data;
 	wnd_data[0].config.width_min = wn;
 	wnd_data[0].config.height_min = hn;
@@ -490,29 +907,42 @@ void g2d_window_style_set(void *const data, const int wn, const int hn, const in
 	wnd_data[0].config.fullscreen = f;
 	wnd_data[0].config.resizable = r;
 	style_update(wnd_data);
+	attributes_update(wnd_data, t, o, s, a);
+	/* negative icon_n keeps icon */
+	if (icon_n >= 0)
+		icons_update(wnd_data, icon_dims, (const unsigned char*)icon_pixels, icon_n);
 }
 
 void g2d_window_fullscreen_set(void *const data, long long *const err1, long long *const err2) {
 	window_data_t *const wnd_data = (window_data_t*)data;
 	if (wnd_data[0].config.fullscreen) {
-		int mx, my, mw, mh; monitor_metrics(MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST), &mx, &my, &mw, &mh);
+		LONG result = DISP_CHANGE_SUCCESSFUL;
 		wnd_data[0].client_bak.x = wnd_data[0].client.x;
 		wnd_data[0].client_bak.y = wnd_data[0].client.y;
 		wnd_data[0].client_bak.width = wnd_data[0].client.width;
 		wnd_data[0].client_bak.height = wnd_data[0].client.height;
-		SetLastError(0); SetWindowLongPtr(wnd_data[0].wnd.hndl, GWL_STYLE, 0);
-		err2[0] = (long long)GetLastError();
-		if (err2[0] == 0) {
-			if (SetWindowPos(wnd_data[0].wnd.hndl, HWND_TOP, mx, my, mw, mh, SWP_NOOWNERZORDER | SWP_FRAMECHANGED | SWP_SHOWWINDOW)) {
-				client_props_update(wnd_data);
-				cursor_clip_update(wnd_data);
+		if (wnd_data[0].mode.exclusive)
+			result = video_mode_change(wnd_data, MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST));
+		if (result == DISP_CHANGE_SUCCESSFUL) {
+			int mx, my, mw, mh; monitor_metrics(MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST), &mx, &my, &mw, &mh);
+			SetLastError(0); SetWindowLongPtr(wnd_data[0].wnd.hndl, GWL_STYLE, 0);
+			err2[0] = (long long)GetLastError();
+			if (err2[0] == 0) {
+				HWND const insert_after = wnd_data[0].mode.exclusive ? HWND_TOPMOST : HWND_TOP;
+				if (SetWindowPos(wnd_data[0].wnd.hndl, insert_after, mx, my, mw, mh, SWP_NOOWNERZORDER | SWP_FRAMECHANGED | SWP_SHOWWINDOW)) {
+					client_props_update(wnd_data);
+					cursor_clip_update(wnd_data);
+				} else {
+					err1[0] = G2D_ERR_1001008; err2[0] = (long long)GetLastError();
+				}
 			} else {
-				err1[0] = G2D_ERR_1001008; err2[0] = (long long)GetLastError();
+				err1[0] = G2D_ERR_1001007;
 			}
 		} else {
-			err1[0] = G2D_ERR_1001007;
+			err1[0] = G2D_ERR_1001024; err2[0] = (long long)result;
 		}
 	} else {
+		video_mode_restore(wnd_data);
 		wnd_data[0].client.x = wnd_data[0].client_bak.x;
 		wnd_data[0].client.y = wnd_data[0].client_bak.y;
 		wnd_data[0].client.width = wnd_data[0].client_bak.width;
@@ -521,7 +951,7 @@ void g2d_window_fullscreen_set(void *const data, long long *const err1, long lon
 		SetLastError(0); SetWindowLongPtr(wnd_data[0].wnd.hndl, GWL_STYLE, wnd_data[0].config.style);
 		err2[0] = (long long)GetLastError();
 		if (err2[0] == 0) {
-			if (SetWindowPos(wnd_data[0].wnd.hndl, HWND_NOTOPMOST, wx, wy, ww, wh, SWP_NOOWNERZORDER | SWP_FRAMECHANGED | SWP_SHOWWINDOW)) {
+			if (SetWindowPos(wnd_data[0].wnd.hndl, wnd_data[0].config.topmost ? HWND_TOPMOST : HWND_NOTOPMOST, wx, wy, ww, wh, SWP_NOOWNERZORDER | SWP_FRAMECHANGED | SWP_SHOWWINDOW)) {
 				cursor_clip_update(wnd_data);
 			} else {
 				err1[0] = G2D_ERR_1001010; err2[0] = (long long)GetLastError();
@@ -532,13 +962,59 @@ void g2d_window_fullscreen_set(void *const data, long long *const err1, long lon
 	}
 }
 
+void g2d_window_video_mode_set(void *const data, const int e, const int w, const int h, const int r, const int b) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	wnd_data[0].mode.exclusive = e;
+	wnd_data[0].mode.width = w;
+	wnd_data[0].mode.height = h;
+	wnd_data[0].mode.rate = r;
+	wnd_data[0].mode.bpp = b;
+}
+
+void g2d_window_video_mode_apply(void *const data, long long *const err1, long long *const err2) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	if (wnd_data[0].config.fullscreen)
+		video_mode_apply(wnd_data, err1, err2);
+}
+
+void g2d_window_hit_regions_set(void *const data, int *const regions, const int n) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	int i;
+	wnd_data[0].hit.n = n < G2D_HIT_REGIONS_MAX ? n : G2D_HIT_REGIONS_MAX;
+	for (i = 0; i < wnd_data[0].hit.n * 5; i++)
+		wnd_data[0].hit.regions[i] = regions[i];
+	/* unknown hit types are client area */
+	for (i = 0; i < wnd_data[0].hit.n; i++)
+		if (regions[i*5+4] < 0 || regions[i*5+4] >= (int)(sizeof(hit_results) / sizeof(hit_results[0])))
+			wnd_data[0].hit.regions[i*5+4] = 0;
+}
+
+void g2d_window_state_set(void *const data, const int sn, const int sx) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	/* before shown, maximized is applied in g2d_window_show */
+	if (!wnd_data[0].state.shown) {
+		wnd_data[0].state.maximized = sx && !wnd_data[0].config.fullscreen;
+	} else if (sn) {
+		if (!wnd_data[0].state.minimized)
+			SendMessage(wnd_data[0].wnd.hndl, WM_SYSCOMMAND, SC_MINIMIZE, 0);
+	} else {
+		if (wnd_data[0].state.minimized)
+			SendMessage(wnd_data[0].wnd.hndl, WM_SYSCOMMAND, SC_RESTORE, 0);
+		/* fullscreen can't be maximized */
+		if (sx && !wnd_data[0].state.maximized && !wnd_data[0].config.fullscreen)
+			ShowWindow(wnd_data[0].wnd.hndl, SW_MAXIMIZE);
+		else if (!sx && wnd_data[0].state.maximized)
+			ShowWindow(wnd_data[0].wnd.hndl, SW_RESTORE);
+	}
+}
+
 void g2d_window_pos_apply(void *const data, long long *const err1, long long *const err2) {
 	window_data_t *const wnd_data = (window_data_t*)data;
 	int wx, wy, ww, wh; window_metrics(wnd_data, &wx, &wy, &ww, &wh);
 	SetLastError(0); SetWindowLongPtr(wnd_data[0].wnd.hndl, GWL_STYLE, wnd_data[0].config.style);
 	err2[0] = (long long)GetLastError();
 	if (err2[0] == 0) {
-		if (SetWindowPos(wnd_data[0].wnd.hndl, HWND_NOTOPMOST, wx, wy, ww, wh, SWP_NOOWNERZORDER | SWP_FRAMECHANGED | SWP_SHOWWINDOW)) {
+		if (SetWindowPos(wnd_data[0].wnd.hndl, wnd_data[0].config.topmost ? HWND_TOPMOST : HWND_NOTOPMOST, wx, wy, ww, wh, SWP_NOOWNERZORDER | SWP_FRAMECHANGED | SWP_SHOWWINDOW)) {
 			wnd_data[0].config.fullscreen = 0;
 			cursor_clip_update(wnd_data);
 		} else {
@@ -578,3 +1054,29 @@ void g2d_mouse_pos_set(void *const data, const int x, const int y, long long *co
 		err1[0] = G2D_ERR_1001022; err2[0] = (long long)GetLastError();
 	}
 }
+
+void g2d_window_monitor_set(void *const data, const int mn, long long *const err1, long long *const err2) {
+	window_data_t *const wnd_data = (window_data_t*)data;
+	HMONITOR const target = monitor_by_index(mn);
+	HMONITOR const current = MonitorFromWindow(wnd_data[0].wnd.hndl, MONITOR_DEFAULTTONEAREST);
+	if (target != current) {
+		int cx, cy, cw, ch, tx, ty, tw, th;
+		monitor_metrics(current, &cx, &cy, &cw, &ch);
+		monitor_metrics(target, &tx, &ty, &tw, &th);
+		if (wnd_data[0].config.fullscreen) {
+			wnd_data[0].client_bak.x += tx - cx;
+			wnd_data[0].client_bak.y += ty - cy;
+			if (SetWindowPos(wnd_data[0].wnd.hndl, HWND_TOP, tx, ty, tw, th, SWP_NOOWNERZORDER | SWP_SHOWWINDOW)) {
+				client_props_update(wnd_data);
+				cursor_clip_update(wnd_data);
+			} else {
+				err1[0] = G2D_ERR_1001023; err2[0] = (long long)GetLastError();
+			}
+		} else {
+			wnd_data[0].client.x += tx - cx;
+			wnd_data[0].client.y += ty - cy;
+			g2d_window_move(data, err1, err2);
+		}
+		monitor_update(wnd_data);
+	}
+}